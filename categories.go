@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/diamondburned/gappdash/internal/desktopentry"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+)
+
+// categoryNames are the names shown for the main categories.
+var categoryNames = map[string]string{
+	"AudioVideo":               "Multimedia",
+	"Development":              "Development",
+	"Graphics":                 "Graphics",
+	"Network":                  "Internet",
+	"Office":                   "Office",
+	"Settings":                 "Settings",
+	"System":                   "System",
+	"Utility":                  "Accessories",
+	"Game":                     "Games",
+	desktopentry.OtherCategory: "Other",
+}
+
+// categoryBar is a sidebar that lists the main categories of applications, so
+// that only the applications of one of them are shown. Its first row shows all
+// applications.
+type categoryBar struct {
+	*gtk.ListBox
+	// categories are the categories of the rows. The first one is empty for
+	// all applications.
+	categories []string
+}
+
+// newCategoryBar creates a new category bar with all applications selected.
+// changed is called when another category is selected.
+func newCategoryBar(changed func()) *categoryBar {
+	categories := append([]string{""}, desktopentry.MainCategories...)
+	categories = append(categories, desktopentry.OtherCategory)
+
+	list := gtk.NewListBox()
+	list.SetSelectionMode(gtk.SelectionBrowse)
+	list.SetVAlign(gtk.AlignStart)
+	addCSSClass(list, "category-bar")
+
+	for i, category := range categories {
+		name := "All"
+		if category != "" {
+			name = categoryNames[category]
+		}
+
+		label := gtk.NewLabel(name)
+		label.SetXAlign(0)
+
+		row := gtk.NewListBoxRow()
+		row.SetCanFocus(false)
+		row.Add(label)
+
+		// Alt+0 to Alt+9 select the first ten rows.
+		if i < 10 {
+			row.SetTooltipText(fmt.Sprintf("Alt+%d", i))
+		}
+
+		list.Add(row)
+	}
+
+	list.SelectRow(list.RowAtIndex(0))
+	list.ConnectRowSelected(func(*gtk.ListBoxRow) { changed() })
+	list.ShowAll()
+
+	return &categoryBar{
+		ListBox:    list,
+		categories: categories,
+	}
+}
+
+// Category returns the selected category, or an empty string if all
+// applications are selected.
+func (b *categoryBar) Category() string {
+	if row := b.SelectedRow(); row != nil {
+		return b.categories[row.Index()]
+	}
+	return ""
+}
+
+// Select selects the category of the row at the given index. It does nothing
+// if there is no such row.
+func (b *categoryBar) Select(index int) {
+	if index >= 0 && index < len(b.categories) {
+		b.SelectRow(b.RowAtIndex(index))
+	}
+}
+
+// Cycle selects the next category, or the previous one if delta is negative,
+// wrapping around at either end.
+func (b *categoryBar) Cycle(delta int) {
+	index := 0
+	if row := b.SelectedRow(); row != nil {
+		index = row.Index()
+	}
+
+	n := len(b.categories)
+	b.Select(((index+delta)%n + n) % n)
+}
@@ -5,10 +5,15 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	_ "embed"
 
+	"github.com/diamondburned/gappdash/internal/appindex"
+	"github.com/diamondburned/gappdash/internal/commands"
+	"github.com/diamondburned/gappdash/internal/desktopentry"
+	"github.com/diamondburned/gappdash/internal/websearch"
 	"github.com/diamondburned/gotk4-layer-shell/pkg/gtklayershell"
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
 	"github.com/pelletier/go-toml"
@@ -20,6 +25,45 @@ type Config struct {
 	App        AppConfig        `toml:"gappdash"`
 	LayerShell LayerShellConfig `toml:"layer-shell"`
 	Window     WindowConfig     `toml:"window"`
+	Providers  ProvidersConfig  `toml:"providers"`
+	Entries    []EntryConfig    `toml:"entry"`
+}
+
+// EntryConfig is the config of a user-defined entry.
+type EntryConfig struct {
+	Name     string
+	Comment  string
+	Icon     string
+	Command  string
+	Keywords []string
+	Terminal bool
+}
+
+// Validate validates the entry.
+func (e *EntryConfig) Validate() error {
+	if e.Name == "" {
+		return errors.New("entry: missing name")
+	}
+	if e.Command == "" {
+		return fmt.Errorf("entry %q: missing command", e.Name)
+	}
+	return nil
+}
+
+// CustomEntries returns the user-defined entries.
+func (c *Config) CustomEntries() []commands.Entry {
+	entries := make([]commands.Entry, len(c.Entries))
+	for i, entry := range c.Entries {
+		entries[i] = commands.Entry{
+			Name:     entry.Name,
+			Comment:  entry.Comment,
+			Icon:     entry.Icon,
+			Command:  entry.Command,
+			Keywords: entry.Keywords,
+			Terminal: entry.Terminal,
+		}
+	}
+	return entries
 }
 
 // AppMode is a string enum type.
@@ -30,16 +74,87 @@ const (
 	ListMode AppMode = "list"
 )
 
+// SortMode is a string enum type.
+type SortMode string
+
+const (
+	SortAlphabetically SortMode = "alphabetical"
+	SortFrecency       SortMode = "frecency"
+)
+
+// EntrySortType returns the desktopentry sort type for the mode. It returns
+// EntryUnsorted for unknown modes.
+func (m SortMode) EntrySortType() desktopentry.EntrySortType {
+	switch m {
+	case SortAlphabetically:
+		return desktopentry.EntrySortedAlphabetically
+	case SortFrecency:
+		return desktopentry.EntrySortedFrecency
+	default:
+		return desktopentry.EntryUnsorted
+	}
+}
+
+// SearcherType is a string enum type.
+type SearcherType string
+
+const (
+	FuzzySearcher     SearcherType = "fuzzy"
+	SubstringSearcher SearcherType = "substring"
+	CollateSearcher   SearcherType = "collate"
+)
+
 // AppConfig is the GAppDash's configuration.
 type AppConfig struct {
 	Mode          AppMode
+	Sort          SortMode
 	Daemonize     bool
 	IndexAge      time.Duration `toml:"index-age"`
-	Fuzzy         bool
+	Searcher      SearcherType
 	CaseSensitive bool `toml:"case-sensitive"`
 	IconSize      int  `toml:"icon-size"`
+	Hidden        []string
+	Pinned        []string
+	Categories    bool
+
+	// Fuzzy is deprecated. If set, it overrides Searcher with either
+	// FuzzySearcher or SubstringSearcher.
+	Fuzzy *bool
 
-	Grid GridConfig
+	Grid    GridConfig
+	Weights WeightsConfig
+	Collate CollateConfig
+}
+
+// SearcherType returns the configured searcher, taking the deprecated fuzzy
+// option into account.
+func (a *AppConfig) SearcherType() SearcherType {
+	if a.Fuzzy != nil {
+		if *a.Fuzzy {
+			return FuzzySearcher
+		}
+		return SubstringSearcher
+	}
+	return a.Searcher
+}
+
+// NewSearcher creates the configured searcher.
+func (a *AppConfig) NewSearcher() appindex.Searcher {
+	return a.NewSearcherWithWeights(a.Weights.Weights())
+}
+
+// NewSearcherWithWeights creates the configured searcher with the given
+// weights instead of the configured ones.
+func (a *AppConfig) NewSearcherWithWeights(weights appindex.Weights) appindex.Searcher {
+	switch a.SearcherType() {
+	case SubstringSearcher:
+		return appindex.NewSubstringSearcher(a.CaseSensitive, weights)
+	case CollateSearcher:
+		lang := desktopentry.CurrentLocale().Tag()
+		return appindex.NewCollateSearcher(lang, a.Collate.CollateOptions(), weights)
+	default:
+		return appindex.NewFuzzySearcher(weights)
+	}
 }
 
 // StockIconSize rounds the config's icon size.
@@ -60,13 +175,186 @@ func (a *AppConfig) Validate() error {
 	if a.Mode != GridMode && a.Mode != ListMode {
 		return fmt.Errorf("unknown mode %q", a.Mode)
 	}
-	return nil
+	if a.Sort.EntrySortType() == desktopentry.EntryUnsorted {
+		return fmt.Errorf("unknown sort %q", a.Sort)
+	}
+	switch a.SearcherType() {
+	case FuzzySearcher, SubstringSearcher, CollateSearcher:
+	default:
+		return fmt.Errorf("unknown searcher %q", a.Searcher)
+	}
+	return a.Weights.Validate()
 }
 
 // GridConfig is the config for grid mode.
 type GridConfig struct {
 	MinChildrenPerLine uint `toml:"min-children-per-line"`
 	MaxChildrenPerLine uint `toml:"max-children-per-line"`
+	// Paged splits the grid into pages that fit the window instead of
+	// scrolling it.
+	Paged bool
+}
+
+// CollateConfig is the config for the collate searcher.
+type CollateConfig struct {
+	IgnoreCase       bool `toml:"ignore-case"`
+	IgnoreDiacritics bool `toml:"ignore-diacritics"`
+	IgnoreWidth      bool `toml:"ignore-width"`
+}
+
+// CollateOptions returns the appindex collate options.
+func (c *CollateConfig) CollateOptions() appindex.CollateOptions {
+	return appindex.CollateOptions{
+		IgnoreCase:       c.IgnoreCase,
+		IgnoreDiacritics: c.IgnoreDiacritics,
+		IgnoreWidth:      c.IgnoreWidth,
+	}
+}
+
+// WeightsConfig is the config for the weight of each searched field.
+type WeightsConfig struct {
+	Name        float64
+	GenericName float64 `toml:"generic-name"`
+	Keywords    float64
+	Categories  float64
+	Executable  float64
+	ID          float64 `toml:"id"`
+	Description float64
+}
+
+// Weights returns the appindex weights.
+func (w *WeightsConfig) Weights() appindex.Weights {
+	return appindex.Weights{
+		appindex.FieldName:        w.Name,
+		appindex.FieldGenericName: w.GenericName,
+		appindex.FieldKeywords:    w.Keywords,
+		appindex.FieldCategories:  w.Categories,
+		appindex.FieldExecutable:  w.Executable,
+		appindex.FieldID:          w.ID,
+		appindex.FieldDescription: w.Description,
+	}
+}
+
+// Validate validates the weights.
+func (w *WeightsConfig) Validate() error {
+	weights := map[string]float64{
+		"weights.name":         w.Name,
+		"weights.generic-name": w.GenericName,
+		"weights.keywords":     w.Keywords,
+		"weights.categories":   w.Categories,
+		"weights.executable":   w.Executable,
+		"weights.id":           w.ID,
+		"weights.description":  w.Description,
+	}
+
+	for what, weight := range weights {
+		if weight < 0 {
+			return fmt.Errorf("%s: negative weight %g not allowed", what, weight)
+		}
+	}
+
+	return nil
+}
+
+// ProvidersConfig is the config for the providers of search results other than
+// applications.
+type ProvidersConfig struct {
+	Calculator  bool
+	Executables bool
+	RecentFiles bool `toml:"recent-files"`
+	Windows     bool
+	Commands    CommandsConfig
+	Emoji       EmojiConfig
+	Power       PowerConfig
+	WebSearch   []WebSearchEngine `toml:"web-search"`
+}
+
+// Engines returns the websearch engines.
+func (p *ProvidersConfig) Engines() []websearch.Engine {
+	engines := make([]websearch.Engine, len(p.WebSearch))
+	for i, engine := range p.WebSearch {
+		engines[i] = websearch.Engine{
+			Name:    engine.Name,
+			Keyword: engine.Keyword,
+			URL:     engine.URL,
+		}
+	}
+	return engines
+}
+
+// Validate validates the providers config.
+func (p *ProvidersConfig) Validate() error {
+	for i, engine := range p.WebSearch {
+		if engine.Name == "" {
+			return fmt.Errorf("web-search %d: missing name", i+1)
+		}
+		if !strings.Contains(engine.URL, "%s") {
+			return fmt.Errorf("web-search %q: url has no %%s", engine.Name)
+		}
+	}
+	return p.Commands.Validate()
+}
+
+// EmojiConfig is the config for the emoji picker.
+type EmojiConfig struct {
+	Prefix      string
+	Characters  bool
+	Annotations string
+}
+
+// PowerConfig is the config for the session and power actions. Actions with
+// empty commands are hidden.
+type PowerConfig struct {
+	Enabled   bool
+	Confirm   bool
+	Lock      string
+	LogOut    string `toml:"log-out"`
+	Suspend   string
+	Hibernate string
+	Reboot    string
+	PowerOff  string `toml:"power-off"`
+}
+
+// Commands returns the commands by the IDs of the power actions.
+func (c *PowerConfig) Commands() map[string]string {
+	return map[string]string{
+		"lock":      c.Lock,
+		"log-out":   c.LogOut,
+		"suspend":   c.Suspend,
+		"hibernate": c.Hibernate,
+		"reboot":    c.Reboot,
+		"power-off": c.PowerOff,
+	}
+}
+
+// WebSearchEngine is the config of a web search engine.
+type WebSearchEngine struct {
+	Name    string
+	Keyword string
+	URL     string
+}
+
+// CommandsConfig is the config for the command mode.
+type CommandsConfig struct {
+	Prefix      string
+	Shell       string
+	Terminal    []string
+	HistorySize int `toml:"history-size"`
+}
+
+// Runner returns the runner of commands.
+func (c *CommandsConfig) Runner() commands.Runner {
+	return commands.Runner{
+		Shell:    c.Shell,
+		Terminal: c.Terminal,
+	}
+}
+
+// Validate validates the command mode config.
+func (c *CommandsConfig) Validate() error {
+	return checkPositiveInts(map[string]int{
+		"commands.history-size": c.HistorySize,
+	})
 }
 
 // LayerShellAnchor is a string enum type.
@@ -199,7 +487,7 @@ func ParseConfig(path string) (*Config, error) {
 		log.Panicln("BUG: error parsing default config:", err)
 	}
 
-	if err := validate(&cfg.LayerShell, &cfg.App); err != nil {
+	if err := validate(&cfg.LayerShell, &cfg.App, &cfg.Providers); err != nil {
 		log.Panicln("BUG: error validating default config:", err)
 	}
 
@@ -218,10 +506,22 @@ func ParseConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
-	if err := validate(&cfg.LayerShell, &cfg.App); err != nil {
+	if err := validate(&cfg.LayerShell, &cfg.App, &cfg.Providers); err != nil {
 		return nil, err
 	}
 
+	names := make(map[string]bool, len(cfg.Entries))
+	for i := range cfg.Entries {
+		if err := cfg.Entries[i].Validate(); err != nil {
+			return nil, err
+		}
+		// The names are the IDs of the entries.
+		if names[cfg.Entries[i].Name] {
+			return nil, fmt.Errorf("entry %q: duplicate name", cfg.Entries[i].Name)
+		}
+		names[cfg.Entries[i].Name] = true
+	}
+
 	return &cfg, nil
 }
 
@@ -234,6 +534,15 @@ func userConfigFile(filename string) (string, error) {
 	return filepath.Join(cfg, "gappdash", filename), nil
 }
 
+func userCacheFile(filename string) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get cache directory")
+	}
+
+	return filepath.Join(cache, "gappdash", filename), nil
+}
+
 // ParseUserConfig parses the configuration file at the default location. If the
 // file does not exist, then a new file with the defaults is created.
 func ParseUserConfig() (*Config, error) {
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/diamondburned/gappdash/internal/appindex"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+)
+
+// confirmPage asks for the confirmation of an item before it is activated.
+type confirmPage struct {
+	*gtk.Box
+	question *gtk.Label
+	confirm  *gtk.Button
+	item     appindex.Item
+}
+
+// newConfirmPage creates a new confirmation page. activate is called with the
+// item once it is confirmed, and cancel is called if it isn't.
+func newConfirmPage(activate func(appindex.Item), cancel func()) *confirmPage {
+	question := gtk.NewLabel("")
+	multilineLabel(question)
+	addCSSClass(question, "confirm-question")
+
+	cancelButton := gtk.NewButtonWithLabel("Cancel")
+	cancelButton.ConnectClicked(cancel)
+
+	confirm := gtk.NewButtonWithLabel("")
+	addCSSClass(confirm, "destructive-action")
+
+	buttons := gtk.NewBox(gtk.OrientationHorizontal, 12)
+	buttons.SetHAlign(gtk.AlignCenter)
+	buttons.Add(cancelButton)
+	buttons.Add(confirm)
+
+	box := gtk.NewBox(gtk.OrientationVertical, 12)
+	box.SetHAlign(gtk.AlignCenter)
+	box.SetVAlign(gtk.AlignCenter)
+	box.Add(question)
+	box.Add(buttons)
+	box.ShowAll()
+	addCSSClass(box, "confirm-page")
+
+	p := &confirmPage{
+		Box:      box,
+		question: question,
+		confirm:  confirm,
+	}
+
+	confirm.ConnectClicked(func() { activate(p.item) })
+
+	return p
+}
+
+// Ask shows the question of the item and focuses the confirm button, so that
+// pressing Enter again confirms it.
+func (p *confirmPage) Ask(item appindex.Item) {
+	p.item = item
+	p.question.SetText(item.Confirm)
+	p.confirm.SetLabel(item.Title)
+	p.confirm.GrabFocus()
+}
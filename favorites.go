@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/diamondburned/gappdash/internal/appindex"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+)
+
+// favoritesRow shows the favorite applications in a grid with a heading. It is
+// hidden while there are no favorites.
+type favoritesRow struct {
+	*gtk.Box
+	grid *gridView
+}
+
+func newFavoritesRow(activate func(appindex.Item)) *favoritesRow {
+	heading := gtk.NewLabel("Favorites")
+	heading.SetXAlign(0)
+	heading.Show()
+	addCSSClass(heading, "favorites-heading")
+
+	grid := newGridView(activate)
+
+	box := gtk.NewBox(gtk.OrientationVertical, 0)
+	box.Add(heading)
+	box.Add(grid)
+	addCSSClass(box, "favorites")
+
+	return &favoritesRow{
+		Box:  box,
+		grid: grid,
+	}
+}
+
+// SetItems replaces the favorites with the given items. Unlike the other
+// views, nothing is selected, since the keyboard navigates the results below.
+func (r *favoritesRow) SetItems(items []appindex.Item) {
+	r.grid.SetItems(items)
+	r.grid.UnselectAll()
+	r.SetVisible(len(items) > 0)
+}
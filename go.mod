@@ -8,6 +8,7 @@ require (
 	github.com/pelletier/go-toml v1.9.4
 	github.com/pkg/errors v0.9.1
 	github.com/sahilm/fuzzy v0.1.0
+	golang.org/x/text v0.3.7
 )
 
 require (
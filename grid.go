@@ -0,0 +1,179 @@
+package main
+
+import (
+	"github.com/diamondburned/gappdash/internal/appindex"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+)
+
+// gridView is the resultView for GridMode.
+type gridView struct {
+	*gtk.FlowBox
+	items    []appindex.Item
+	activate func(appindex.Item)
+
+	// menu keeps the shown actions menu alive.
+	menu *gtk.Menu
+}
+
+func newGridView(activate func(appindex.Item)) *gridView {
+	grid := gtk.NewFlowBox()
+	grid.SetActivateOnSingleClick(true)
+	grid.SetVAlign(gtk.AlignStart)
+	grid.SetHAlign(gtk.AlignCenter)
+	grid.SetHomogeneous(true)
+	grid.SetMinChildrenPerLine(app.cfg.App.Grid.MinChildrenPerLine)
+	grid.SetMaxChildrenPerLine(app.cfg.App.Grid.MaxChildrenPerLine)
+	grid.SetSelectionMode(gtk.SelectionSingle)
+	grid.Show()
+	addCSSClass(grid, "app-grid")
+
+	v := &gridView{
+		FlowBox:  grid,
+		activate: activate,
+	}
+
+	grid.Connect("child-activated", func(child *gtk.FlowBoxChild) {
+		activate(v.items[child.Index()])
+	})
+
+	return v
+}
+
+func (v *gridView) SetItems(items []appindex.Item) {
+	v.items = items
+	removeChildren(&v.Container)
+
+	for i, item := range items {
+		icon := newItemIcon(item)
+
+		label := newHighlightedLabel(item.Title, item.TitleRanges)
+		label.SetTooltipText(item.Title)
+		label.SetYAlign(1)
+		singlelineLabel(label)
+
+		overlay := gtk.NewOverlay()
+		overlay.Add(icon)
+		overlay.AddOverlay(label)
+
+		child := gtk.NewFlowBoxChild()
+
+		evbox := gtk.NewEventBox()
+		addCSSClass(evbox, "grid-item")
+		evbox.AddEvents(int(gdk.EnterNotifyMask | gdk.LeaveNotifyMask))
+		evbox.Connect("enter-notify-event", func() {
+			multilineLabel(label)
+			addCSSClass(evbox, "hover")
+		})
+		evbox.Connect("leave-notify-event", func() {
+			singlelineLabel(label)
+			removeCSSClass(evbox, "hover")
+		})
+		evbox.Connect("button-press-event", func(event *gdk.Event) bool {
+			if event.AsButton().Button() != gdk.BUTTON_SECONDARY {
+				return false
+			}
+
+			v.SelectChild(child)
+			v.popupActions(child, event)
+			return true
+		})
+		evbox.Add(overlay)
+
+		if item.Subtitle != "" {
+			label.SetTooltipText(item.Title + "\n" + item.Subtitle)
+		}
+		if item.Class != "" {
+			addCSSClass(evbox, item.Class)
+		}
+
+		child.Add(evbox)
+
+		if i == 0 {
+			v.SelectChild(child)
+		}
+
+		v.Add(child)
+	}
+
+	v.ShowAll()
+}
+
+func (v *gridView) SelectedItem() (appindex.Item, bool) {
+	if selected := v.SelectedChildren(); len(selected) > 0 {
+		return v.items[selected[0].Index()], true
+	}
+	return appindex.Item{}, false
+}
+
+func (v *gridView) SelectedWidget() gtk.Widgetter {
+	if selected := v.SelectedChildren(); len(selected) > 0 {
+		return &selected[0]
+	}
+	return nil
+}
+
+func (v *gridView) MoveSelection(step selectionStep, count int) bool {
+	if len(v.items) == 0 {
+		return false
+	}
+
+	index := 0
+	lines := 1
+
+	if selected := v.SelectedChildren(); len(selected) > 0 {
+		index = selected[0].Index()
+		lines = pageLines(&selected[0])
+	}
+
+	index = moveIndex(index, len(v.items), v.columns(), lines, step, count)
+	v.SelectChild(v.ChildAtIndex(index))
+	return true
+}
+
+// columns returns the number of children in the first line of the grid.
+func (v *gridView) columns() int {
+	first := v.ChildAtIndex(0)
+	if first == nil {
+		return 1
+	}
+
+	y := first.Allocation().Y()
+	columns := 1
+
+	for child := v.ChildAtIndex(columns); child != nil; child = v.ChildAtIndex(columns) {
+		if child.Allocation().Y() != y {
+			break
+		}
+		columns++
+	}
+
+	return columns
+}
+
+func (v *gridView) ActivateSelected() {
+	if selected := v.SelectedChildren(); len(selected) > 0 {
+		selected[0].Activate()
+	}
+}
+
+func (v *gridView) PopupSelectedActions() {
+	if selected := v.SelectedChildren(); len(selected) > 0 {
+		v.popupActions(&selected[0], nil)
+	}
+}
+
+// popupActions shows the actions menu for the given child. If event is nil,
+// then the menu is shown below the child instead of at the pointer.
+func (v *gridView) popupActions(child *gtk.FlowBoxChild, event *gdk.Event) {
+	v.menu = newActionsMenu(v.items[child.Index()], v.activate)
+	if v.menu == nil {
+		return
+	}
+
+	if event != nil {
+		v.menu.PopupAtPointer(event)
+	} else {
+		v.menu.PopupAtWidget(child, gdk.GravitySouth, gdk.GravityNorth, nil)
+	}
+}
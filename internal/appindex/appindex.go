@@ -1,117 +1,226 @@
-// Package appindex provides an application file lister, indexer and searcher.
+// Package appindex provides an application file lister, indexer and searcher,
+// as well as an index that merges the results of multiple providers.
 package appindex
 
 import (
+	"sort"
 	"strings"
 	"sync"
-	"time"
-
-	"github.com/diamondburned/gappdash/internal/desktopentry"
-	"github.com/diamondburned/gotk4/pkg/gio/v2"
 )
 
-// Index is the application indexer. All its methods are thread-safe.
-type Index struct {
-	Searcher Searcher
-	MaxAge   time.Duration
-	SortType desktopentry.EntrySortType
+// TopScore is the score of items that should come before everything else,
+// such as the result of a calculation.
+const TopScore = 1000
+
+// BottomScore is the score of items that should come after the items of the
+// Searchers, such as the executables in $PATH. Providers may add up to 1 to it
+// to rank their own items.
+const BottomScore = -1000
+
+// Item is a generic result of a Provider.
+type Item struct {
+	// ID identifies the item among the items of its provider.
+	ID       string
+	Title    string
+	Subtitle string
+	// Icon is a GIcon string, which is either an icon name or an absolute
+	// path to an image.
+	Icon string
+	// Glyph is text that is shown instead of the icon if it is not empty,
+	// such as an emoji.
+	Glyph string
+	// Score is the relevance of the item to the search query. Items from all
+	// providers are sorted by it, so providers should keep it within the
+	// range of the scores of the Searchers, which is around 1 for a good match
+	// of a single word.
+	Score float64
+	// TitleRanges and SubtitleRanges contain the parts of Title and Subtitle
+	// that matched the search query.
+	TitleRanges    []Range
+	SubtitleRanges []Range
+	// Class is an optional CSS class for the widget of the item.
+	Class string
+	// Activate is called when the item is activated.
+	Activate func()
+	// Confirm is a question that has to be confirmed before the item is
+	// activated, or an empty string if the item is activated right away.
+	Confirm string
+	// Actions returns the secondary items of the item, which are shown in its
+	// context menu. It is nil if the item has none.
+	Actions func() []Item
+	// Category is the main XDG category of the item, such as "Development",
+	// or an empty string if the item isn't an application.
+	Category string
+	// FavoriteID is the ID that the item is added to the Favorites with, or
+	// an empty string if it cannot be a favorite.
+	FavoriteID string
+	// Completion is the query that the item completes to, or an empty string
+	// if the item cannot be completed.
+	Completion string
+}
 
-	searchResults []gio.AppInfor
+// Provider describes a source of items. Its methods must be thread-safe.
+type Provider interface {
+	// Search returns the items that match the given query.
+	Search(query string) []Item
+}
 
-	mutex   sync.Mutex
-	entries entryIndex
+// Lister is a Provider that can also list its items without a query. Listed
+// items are shown while the query is empty.
+type Lister interface {
+	Provider
+	// List returns the items to be shown when there is no query.
+	List() []Item
+}
 
-	reindexing bool
+// PrefixProvider is a Provider that is only searched when the query starts with
+// its prefix, in which case it is the only provider searched. Its Search method
+// is given the query without the prefix.
+type PrefixProvider interface {
+	Provider
+	// Prefix returns the prefix of the queries for the provider.
+	Prefix() string
 }
 
-type entryIndex struct {
-	entries       []gio.AppInfor
-	searchEntries []string
-	lastIndexed   time.Time
+// FallbackProvider is a Provider that also has items for queries that no
+// application matches, such as searching the web for the query.
+type FallbackProvider interface {
+	Provider
+	// Fallback returns the items to be shown when no application matches the
+	// query.
+	Fallback(query string) []Item
 }
 
-// NewIndex creates a new indexer.
-func NewIndex(searcher Searcher) *Index {
-	return &Index{
-		Searcher:      searcher,
-		MaxAge:        30 * time.Minute,
-		SortType:      desktopentry.EntrySortedModTimeReverse,
-		searchResults: make([]gio.AppInfor, 0, 50),
-	}
+// Suppressor is a Provider of applications or of things like them, which are
+// what the user is usually looking for. If it has items for a query, then the
+// fallback items of every FallbackProvider aren't shown.
+type Suppressor interface {
+	Provider
+	// SuppressesFallback returns true if the items of the provider suppress
+	// the fallback items.
+	SuppressesFallback() bool
 }
 
-// AllEntries returns all entries.
-func (i *Index) AllEntries() []gio.AppInfor {
-	i.mutex.Lock()
-	defer i.mutex.Unlock()
+// Index searches multiple providers and merges their results. All its methods
+// are thread-safe.
+type Index struct {
+	mutex     sync.Mutex
+	providers []Provider
+}
 
-	return i.entries.entries
+// NewIndex creates a new index with the given providers.
+func NewIndex(providers ...Provider) *Index {
+	return &Index{providers: providers}
 }
 
-// Search searches the index for the given query.
-func (i *Index) Search(query string) []gio.AppInfor {
+// Register adds the given provider to the index.
+func (i *Index) Register(provider Provider) {
+	i.mutex.Lock()
+	i.providers = append(i.providers, provider)
+	i.mutex.Unlock()
+}
+
+func (i *Index) snapshotProviders() []Provider {
 	i.mutex.Lock()
 	defer i.mutex.Unlock()
 
-	if i.entries.lastIndexed.Add(i.MaxAge).Before(time.Now()) && !i.reindexing {
-		// Expired. Queue a reindexing.
-		i.reindexing = true
-		go i.asyncReindex(func() { i.reindexing = false })
-	}
+	return i.providers[:len(i.providers):len(i.providers)]
+}
+
+// List returns the listed items of every Lister in the order that they were
+// registered.
+func (i *Index) List() []Item {
+	var items []Item
 
-	i.searchResults = i.searchResults[:0]
-	for _, idx := range i.Searcher.Search(query) {
-		i.searchResults = append(i.searchResults, i.entries.entries[idx])
+	for _, provider := range i.snapshotProviders() {
+		if lister, ok := provider.(Lister); ok {
+			items = append(items, lister.List()...)
+		}
 	}
 
-	return i.searchResults
+	return items
 }
 
-// Reindex forces the index to be reindexed synchronously.
-func (i *Index) Reindex() {
-	i.asyncReindex(nil)
+// Prefixed returns true if the query starts with the prefix of a
+// PrefixProvider, in which case only that provider is searched.
+func (i *Index) Prefixed(query string) bool {
+	return findPrefixProvider(i.snapshotProviders(), query) != nil
 }
 
-func (i *Index) asyncReindex(then func()) {
-	// TODO: reuse a buffer.
-	idx := entryIndex{}
-	forceReindex(i.SortType, &idx)
-
-	i.mutex.Lock()
-
-	i.entries = idx
-	// TODO: decouple indexing from the searcher.
-	i.Searcher.Index(i.entries.searchEntries)
+func findPrefixProvider(providers []Provider, query string) PrefixProvider {
+	for _, provider := range providers {
+		prefixed, ok := provider.(PrefixProvider)
+		if ok && prefixed.Prefix() != "" && strings.HasPrefix(query, prefixed.Prefix()) {
+			return prefixed
+		}
+	}
+	return nil
+}
 
-	if then != nil {
-		then()
+// Search searches every provider for the given query and returns the merged
+// items sorted by their scores. Items with the same score stay in the order
+// that their providers were registered in. If no Suppressor has items for the
+// query, then the fallback items of every FallbackProvider are appended in that
+// order, since the other providers rarely have what is looked for.
+// If the query starts with the prefix of a PrefixProvider, then only its items
+// are returned in its order.
+func (i *Index) Search(query string) []Item {
+	providers := i.snapshotProviders()
+
+	if prefixed := findPrefixProvider(providers, query); prefixed != nil {
+		return prefixed.Search(strings.TrimPrefix(query, prefixed.Prefix()))
 	}
 
-	i.mutex.Unlock()
-}
+	var items []Item
+	var suppressed bool
 
-func forceReindex(sortType desktopentry.EntrySortType, idx *entryIndex) {
-	idx.entries = desktopentry.List(sortType)
+	for _, provider := range providers {
+		if _, ok := provider.(PrefixProvider); ok {
+			continue
+		}
 
-	if cap(idx.searchEntries) >= len(idx.entries) {
-		idx.searchEntries = idx.searchEntries[:0]
-	} else {
-		idx.searchEntries = make([]string, 0, len(idx.entries))
+		found := provider.Search(query)
+		if suppressor, ok := provider.(Suppressor); ok && len(found) > 0 {
+			suppressed = suppressed || suppressor.SuppressesFallback()
+		}
+
+		items = append(items, found...)
 	}
 
-	for _, entry := range idx.entries {
-		idx.searchEntries = append(idx.searchEntries, buildEntryQuery(entry))
+	SortItems(items)
+
+	if !suppressed {
+		for _, provider := range providers {
+			if fallback, ok := provider.(FallbackProvider); ok {
+				items = append(items, fallback.Fallback(query)...)
+			}
+		}
 	}
 
-	idx.lastIndexed = time.Now()
+	return items
 }
 
-func buildEntryQuery(entry gio.AppInfor) string {
-	list := []string{
-		entry.DisplayName(),
-		entry.Description(),
-		entry.Executable(),
+// SortItems sorts the items by their scores, highest first. Items with the same
+// score keep their order.
+func SortItems(items []Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Score > items[j].Score
+	})
+}
+
+// FilterCategory returns the items of the given category. Items that aren't
+// applications have no category, so they are filtered out. If category is
+// empty, then the items are returned as they are.
+func FilterCategory(items []Item, category string) []Item {
+	if category == "" {
+		return items
 	}
 
-	return strings.Join(list, " ")
+	filtered := make([]Item, 0, len(items))
+	for _, item := range items {
+		if item.Category == category {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
 }
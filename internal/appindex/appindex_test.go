@@ -0,0 +1,281 @@
+package appindex
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/gappdash/internal/desktopentry"
+)
+
+const nautilusDesktop = `
+[Desktop Entry]
+Type=Application
+Name=Files
+Name[de]=Dateien
+GenericName=File Manager
+GenericName[de]=Dateimanager
+Keywords=folder;manager;explore;
+Keywords[de]=Ordner;Verwaltung;folder;
+Categories=GNOME;GTK;Utility;Core;FileManager;
+Exec=nautilus --new-window %U
+`
+
+type staticProvider []Item
+
+func (p staticProvider) Search(query string) []Item {
+	var items []Item
+	for _, item := range p {
+		if strings.Contains(item.Title, query) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+type staticLister struct{ staticProvider }
+
+func (l staticLister) List() []Item { return l.staticProvider }
+
+func TestIndex(t *testing.T) {
+	apps := staticLister{staticProvider{
+		{ID: "calc.desktop", Title: "Calculator", Score: 0.8},
+		{ID: "term.desktop", Title: "Terminal", Score: 0.5},
+	}}
+	calc := staticProvider{
+		{ID: "1+1", Title: "1+1 = 2", Score: 0.8},
+	}
+
+	idx := NewIndex(apps)
+	idx.Register(calc)
+
+	if items := idx.List(); len(items) != 2 {
+		t.Errorf("expected only the listed items, got %v", items)
+	}
+
+	items := idx.Search("")
+
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
+	expected := []string{"calc.desktop", "1+1", "term.desktop"}
+	if strings.Join(ids, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected items %q, got %q", expected, ids)
+	}
+}
+
+type prefixProvider struct{ staticProvider }
+
+func (p prefixProvider) Prefix() string { return ">" }
+
+func TestIndexPrefix(t *testing.T) {
+	apps := staticProvider{{ID: "make.desktop", Title: "make"}}
+	commands := prefixProvider{staticProvider{{ID: "make", Title: "make"}}}
+
+	idx := NewIndex(apps, commands)
+
+	if items := idx.Search("make"); len(items) != 1 || items[0].ID != "make.desktop" {
+		t.Errorf("expected only the application without the prefix, got %v", items)
+	}
+
+	if items := idx.Search(">make"); len(items) != 1 || items[0].ID != "make" {
+		t.Errorf("expected only the command with the prefix, got %v", items)
+	}
+
+	if !idx.Prefixed(">make") || idx.Prefixed("make") {
+		t.Error("unexpected Prefixed results")
+	}
+}
+
+func TestBuildRecord(t *testing.T) {
+	file, err := desktopentry.Parse(strings.NewReader(nautilusDesktop))
+	if err != nil {
+		t.Fatal("cannot parse:", err)
+	}
+
+	entry, err := desktopentry.NewEntry(file, desktopentry.ParseLocale("de_DE.UTF-8"))
+	if err != nil {
+		t.Fatal("cannot create entry:", err)
+	}
+	entry.ID = "org.gnome.Nautilus.desktop"
+
+	record := buildRecord(Result{Entry: entry})
+
+	expected := Record{
+		FieldName:        "Dateien",
+		FieldGenericName: "Dateimanager File Manager",
+		FieldKeywords:    "Ordner Verwaltung folder manager explore",
+		FieldCategories:  "GNOME GTK Utility Core File Manager",
+		FieldExecutable:  "nautilus",
+		FieldID:          "org.gnome.Nautilus",
+	}
+
+	for field := range expected {
+		if record[field] != expected[field] {
+			t.Errorf("field %d: expected %q, got %q", field, expected[field], record[field])
+		}
+	}
+
+	searcher := NewSubstringSearcher(false, DefaultWeights)
+	searcher.Index([]Record{record})
+
+	for _, query := range []string{"file manager", "explore", "ordner"} {
+		if matches := searcher.Search(query); len(matches) != 1 {
+			t.Errorf("query %q did not match", query)
+		}
+	}
+}
+
+type fallbackProvider struct{ staticProvider }
+
+func (p fallbackProvider) Fallback(query string) []Item {
+	return []Item{{ID: "search " + query}}
+}
+
+type suppressorProvider struct{ staticProvider }
+
+func (suppressorProvider) SuppressesFallback() bool { return true }
+
+func TestIndexFallback(t *testing.T) {
+	apps := suppressorProvider{staticProvider{{ID: "firefox.desktop", Title: "Firefox"}}}
+	calc := staticProvider{{ID: "= 5", Title: "Firefox 2+3"}}
+	web := fallbackProvider{staticProvider{{ID: "https://example.com", Title: "example.com"}}}
+
+	idx := NewIndex(apps, calc, web)
+
+	if items := idx.Search("Fire"); len(items) != 2 || items[0].ID != "firefox.desktop" {
+		t.Errorf("expected no fallback with applications, got %v", items)
+	}
+
+	if items := idx.Search("example.com"); len(items) != 2 || items[0].ID != "https://example.com" || items[1].ID != "search example.com" {
+		t.Errorf("expected the normal results of the fallback provider before its fallback, got %v", items)
+	}
+
+	if items := idx.Search("cats"); len(items) != 1 || items[0].ID != "search cats" {
+		t.Errorf("expected the fallback, got %v", items)
+	}
+}
+
+func TestFilterCategory(t *testing.T) {
+	items := []Item{
+		{ID: "gimp.desktop", Category: "Graphics"},
+		{ID: "calc", Title: "= 2"},
+		{ID: "inkscape.desktop", Category: "Graphics"},
+		{ID: "foot.desktop", Category: "System"},
+	}
+
+	var ids []string
+	for _, item := range FilterCategory(items, "Graphics") {
+		ids = append(ids, item.ID)
+	}
+
+	if expected := "gimp.desktop,inkscape.desktop"; strings.Join(ids, ",") != expected {
+		t.Errorf("expected %s, got %q", expected, ids)
+	}
+
+	if all := FilterCategory(items, ""); len(all) != len(items) {
+		t.Errorf("expected all items without a category, got %v", all)
+	}
+}
+
+func TestBuildHiddenPinned(t *testing.T) {
+	idx := entryIndex{byID: map[string]*desktopentry.Entry{}}
+	for _, name := range []string{"Avahi", "Browser", "Calendar", "Editor", "Terminal"} {
+		id := strings.ToLower(name) + ".desktop"
+		idx.byID[id] = &desktopentry.Entry{ID: id, Name: name}
+	}
+
+	hidden := []string{"avahi.desktop"}
+	pinned := []string{"terminal.desktop", "missing.desktop", "calendar.desktop"}
+
+	idx.build(desktopentry.EntrySortedAlphabetically, hidden, pinned, nil)
+
+	var names []string
+	for _, item := range idx.items {
+		names = append(names, item.Title)
+	}
+
+	expected := []string{"Terminal", "Calendar", "Browser", "Editor"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected entries %q, got %q", expected, names)
+	}
+
+	if len(idx.pinned) != 2 || !idx.pinned["terminal.desktop"] || !idx.pinned["calendar.desktop"] {
+		t.Errorf("unexpected pinned IDs %v", idx.pinned)
+	}
+
+	matches := []Match{{Index: 2, Score: 1}, {Index: 1, Score: 0.8}}
+	boostPinned(matches, idx.results, idx.pinned)
+
+	if matches[0].Score != 1 || matches[1].Score < 1.19 {
+		t.Errorf("expected only the pinned entry to be boosted, got %v", matches)
+	}
+}
+
+func TestBuildExtra(t *testing.T) {
+	idx := entryIndex{byID: map[string]*desktopentry.Entry{
+		"editor.desktop": {ID: "editor.desktop", Name: "Editor", Exec: "editor"},
+	}}
+
+	extra := []*desktopentry.Entry{
+		{ID: "custom:Deploy", Name: "Deploy", Exec: "sh -c deploy"},
+		{ID: "custom:Hidden", Name: "Hidden", Exec: "true"},
+	}
+
+	idx.build(desktopentry.EntrySortedAlphabetically, []string{"custom:Hidden"}, nil, extra)
+
+	if len(idx.items) != 2 || idx.items[0].Title != "Deploy" || idx.items[0].FavoriteID != "custom:Deploy" {
+		t.Errorf("expected the extra entry to be sorted with the others, got %+v", idx.items)
+	}
+
+	if !idx.graphical["editor"] || idx.graphical["sh"] {
+		t.Errorf("unexpected graphical executables %v", idx.graphical)
+	}
+}
+
+func TestAppsResort(t *testing.T) {
+	// Keep the launches out of the history of the user.
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	apps := NewApps(NewSubstringSearcher(false, DefaultWeights))
+	apps.SortType = desktopentry.EntrySortedFrecency
+	apps.Pinned = []string{"terminal.desktop"}
+
+	idx := entryIndex{byID: map[string]*desktopentry.Entry{}, lastIndexed: time.Now()}
+	for _, name := range []string{"Browser", "Editor", "Terminal"} {
+		id := strings.ToLower(name) + ".desktop"
+		idx.byID[id] = &desktopentry.Entry{ID: id, Name: name, Exec: "true"}
+	}
+
+	apps.swap(&idx, nil)
+
+	listNames := func(items []Item) string {
+		names := make([]string, len(items))
+		for i, item := range items {
+			names[i] = item.Title
+		}
+		return strings.Join(names, ",")
+	}
+
+	listed := apps.List()
+	if names := listNames(listed); names != "Terminal,Browser,Editor" {
+		t.Fatalf("unexpected order before launching: %s", names)
+	}
+
+	listed[2].Activate()
+	apps.Resort()
+
+	if names := listNames(apps.List()); names != "Terminal,Editor,Browser" {
+		t.Errorf("expected the launched entry after the pinned one, got %s", names)
+	}
+
+	if names := listNames(listed); names != "Terminal,Browser,Editor" {
+		t.Errorf("the previously listed items changed to %s", names)
+	}
+
+	if items := apps.Items([]string{"editor.desktop"}); len(items) != 1 || items[0].Title != "Editor" {
+		t.Errorf("unexpected items after resorting: %v", items)
+	}
+}
@@ -0,0 +1,622 @@
+package appindex
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/diamondburned/gappdash/internal/desktopentry"
+)
+
+// Result is an application in the index or one of its actions.
+type Result struct {
+	Entry *desktopentry.Entry
+	// Action is the action of the entry, or nil if the result is the entry
+	// itself.
+	Action *desktopentry.Action
+}
+
+// Name returns the name of the result to be displayed.
+func (r Result) Name() string {
+	if r.Action != nil {
+		return r.Action.Name
+	}
+	return r.Entry.Name
+}
+
+// Description returns the description of the result to be displayed. For
+// actions, it is the name of the application.
+func (r Result) Description() string {
+	if r.Action != nil {
+		return r.Entry.Name
+	}
+	return r.Entry.Comment
+}
+
+// Icon returns the icon of the result. Actions without icons use the icon of
+// the application.
+func (r Result) Icon() string {
+	if r.Action != nil && r.Action.Icon != "" {
+		return r.Action.Icon
+	}
+	return r.Entry.Icon
+}
+
+// Exec launches the result.
+func (r Result) Exec() {
+	if r.Action != nil {
+		desktopentry.ExecAction(r.Entry, r.Action)
+	} else {
+		desktopentry.Exec(r.Entry)
+	}
+}
+
+// Item returns the result as an item. The ID of the item is the desktop file
+// ID, followed by a space and the action ID for actions. The actions of the
+// item are the actions of the application. Only applications can be
+// favorites.
+func (r Result) Item() Item {
+	item := Item{
+		ID:       r.Entry.ID,
+		Title:    r.Name(),
+		Subtitle: r.Description(),
+		Icon:     r.Icon(),
+		Category: r.Entry.MainCategory(),
+		Activate: r.Exec,
+	}
+
+	if r.Action != nil {
+		item.ID += " " + r.Action.ID
+		item.Class = "action"
+	} else {
+		item.FavoriteID = r.Entry.ID
+	}
+
+	if entry := r.Entry; len(entry.Actions) > 0 {
+		item.Actions = func() []Item {
+			actions := make([]Item, len(entry.Actions))
+			for i, action := range entry.Actions {
+				actions[i] = Result{Entry: entry, Action: action}.Item()
+			}
+			return actions
+		}
+	}
+
+	return item
+}
+
+// Apps is the Provider of desktop applications and their actions. It indexes
+// the desktop files in the applications directories. All its methods are
+// thread-safe.
+type Apps struct {
+	Searcher Searcher
+	// MaxAge is the maximum age of the index before Search reindexes it in
+	// the background. It is not used while the index is watching.
+	MaxAge   time.Duration
+	SortType desktopentry.EntrySortType
+	// Hidden contains the desktop IDs of the entries that are never shown.
+	Hidden []string
+	// Pinned contains the desktop IDs of the entries that are always listed
+	// first, in this order. They are also ranked higher in search results.
+	Pinned []string
+	// Extra contains the entries that have no desktop files, such as the
+	// user-defined ones. They are listed and searched like the applications,
+	// and their IDs must not be desktop file IDs.
+	Extra []*desktopentry.Entry
+	// SnapshotPath is the path to save the index to after it is updated. The
+	// index is not saved if this is empty. See LoadSnapshot.
+	SnapshotPath string
+
+	mutex    sync.Mutex
+	entries  entryIndex
+	onUpdate func()
+	watcher  *watcher
+
+	// updateMutex serializes the writers of the index, so that incremental
+	// updates never race with a full reindex.
+	updateMutex sync.Mutex
+	reindexing  bool
+}
+
+type entryIndex struct {
+	// files maps each desktop file ID to the path of the desktop file that
+	// takes precedence, including files that aren't shown.
+	files map[string]string
+	// modTimes maps each desktop file ID to the modification time of its file
+	// when it was read, including files that aren't shown.
+	modTimes map[string]time.Time
+	// byID maps each desktop file ID to its entry. It only contains entries
+	// that are shown.
+	byID map[string]*desktopentry.Entry
+
+	// entries contains a result for each entry without its actions.
+	entries []Result
+	// items contains the item of each result in entries.
+	items []Item
+	// itemIndices maps the desktop IDs of the entries to their indices in
+	// items.
+	itemIndices map[string]int
+	// results contains everything that can be searched, which is every entry
+	// followed by every action.
+	results []Result
+	// records contains the searchable record of each result.
+	records []Record
+	// graphical contains the base names of the executables of the entries
+	// that do not run in a terminal.
+	graphical map[string]bool
+	// windowClasses maps the lowercase window classes of the entries to them.
+	windowClasses map[string]*desktopentry.Entry
+	// pinned contains the desktop IDs of the pinned entries that are shown.
+	pinned      map[string]bool
+	lastIndexed time.Time
+}
+
+// NewApps creates a new application indexer.
+func NewApps(searcher Searcher) *Apps {
+	return &Apps{
+		Searcher: searcher,
+		MaxAge:   30 * time.Minute,
+		SortType: desktopentry.EntrySortedModTimeReverse,
+	}
+}
+
+// OnUpdate sets the function to be called after the index is updated. It is
+// called from a background goroutine.
+func (a *Apps) OnUpdate(f func()) {
+	a.mutex.Lock()
+	a.onUpdate = f
+	a.mutex.Unlock()
+}
+
+// List returns the items of all entries. Actions are not included.
+func (a *Apps) List() []Item {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.entries.items
+}
+
+// SuppressesFallback implements Suppressor.
+func (a *Apps) SuppressesFallback() bool {
+	return true
+}
+
+// Resort sorts the listed entries again by the launch history if they are
+// sorted by frecency, since they are otherwise only sorted when the index is
+// updated. Pinned entries stay first. The function given to OnUpdate is called
+// afterwards.
+func (a *Apps) Resort() {
+	a.mutex.Lock()
+
+	if a.SortType != desktopentry.EntrySortedFrecency {
+		a.mutex.Unlock()
+		return
+	}
+
+	a.entries.sortItems(desktopentry.UserHistory(), time.Now())
+	onUpdate := a.onUpdate
+
+	a.mutex.Unlock()
+
+	if onUpdate != nil {
+		onUpdate()
+	}
+}
+
+// Items returns the items of the entries with the given desktop IDs in the
+// same order. IDs of entries that aren't shown are skipped.
+func (a *Apps) Items(ids []string) []Item {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	items := make([]Item, 0, len(ids))
+	for _, id := range ids {
+		if i, ok := a.entries.itemIndices[id]; ok {
+			items = append(items, a.entries.items[i])
+		}
+	}
+
+	return items
+}
+
+// IsGraphical returns true if the executable of the given name is run by an
+// application that does not run in a terminal.
+func (a *Apps) IsGraphical(executable string) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.entries.graphical[executable]
+}
+
+// WindowApp returns the name and icon of the application of windows with the
+// given class or Wayland app ID. Empty strings are returned if no application
+// is known to have it.
+func (a *Apps) WindowApp(class string) (name, icon string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	entry, ok := a.entries.windowClasses[strings.ToLower(class)]
+	if !ok {
+		return "", ""
+	}
+	return entry.Name, entry.Icon
+}
+
+// Search searches the index for the given query. Both entries and their
+// actions are searched. The title and subtitle ranges of the items are the
+// matched ranges of the name and description.
+func (a *Apps) Search(query string) []Item {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	expired := a.entries.lastIndexed.Add(a.MaxAge).Before(time.Now())
+	if expired && a.watcher == nil && !a.reindexing {
+		// Expired. Queue a reindexing.
+		a.reindexing = true
+		go a.asyncReindex(func() { a.reindexing = false })
+	}
+
+	matches := a.Searcher.Search(query)
+
+	boosted := false
+	if a.SortType == desktopentry.EntrySortedFrecency {
+		boostFrecency(matches, a.entries.results)
+		boosted = true
+	}
+	if len(a.entries.pinned) > 0 {
+		boostPinned(matches, a.entries.results, a.entries.pinned)
+		boosted = true
+	}
+
+	if boosted {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].Score > matches[j].Score
+		})
+	}
+
+	items := make([]Item, len(matches))
+	for i, match := range matches {
+		item := a.entries.results[match.Index].Item()
+		item.Score = match.Score
+		item.TitleRanges = match.Ranges[FieldName]
+		item.SubtitleRanges = match.Ranges[FieldDescription]
+		items[i] = item
+	}
+
+	return items
+}
+
+// sortItems sorts the items by their frecency in the given history, keeping
+// the pinned entries first. Entries with the same frecency keep their order.
+// The items are copied, since List may have returned them.
+func (idx *entryIndex) sortItems(history *desktopentry.History, now time.Time) {
+	items := append([]Item(nil), idx.items...)
+
+	frecencies := make(map[string]float64, len(items))
+	for _, item := range items {
+		frecencies[item.ID] = history.Frecency(item.ID, now)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		iPinned, jPinned := idx.pinned[items[i].ID], idx.pinned[items[j].ID]
+		if iPinned || jPinned {
+			return iPinned && !jPinned
+		}
+		return frecencies[items[i].ID] > frecencies[items[j].ID]
+	})
+
+	idx.items = items
+	idx.itemIndices = make(map[string]int, len(items))
+	for i, item := range items {
+		idx.itemIndices[item.ID] = i
+	}
+}
+
+// boostFrecency boosts the scores of the matches by the frecency of their
+// entries, so that the most used entries come first among similarly relevant
+// ones. The matches have to be sorted again afterwards.
+func boostFrecency(matches []Match, results []Result) {
+	history := desktopentry.UserHistory()
+	now := time.Now()
+
+	frecencies := make(map[string]float64, len(matches))
+
+	for j, match := range matches {
+		id := results[match.Index].Entry.ID
+
+		frecency, ok := frecencies[id]
+		if !ok {
+			frecency = history.Frecency(id, now)
+			frecencies[id] = frecency
+		}
+
+		// A single launch today has a frecency of 100. The boost approaches
+		// 50% for the most used entries, so relevance still matters more.
+		matches[j].Score *= 1 + 0.5*frecency/(frecency+100)
+	}
+}
+
+// pinnedBoost is the factor of the scores of pinned entries.
+const pinnedBoost = 1.5
+
+// boostPinned boosts the scores of the matches of pinned entries. Their actions
+// aren't boosted. The matches have to be sorted again afterwards.
+func boostPinned(matches []Match, results []Result, pinned map[string]bool) {
+	for j, match := range matches {
+		if result := results[match.Index]; result.Action == nil && pinned[result.Entry.ID] {
+			matches[j].Score *= pinnedBoost
+		}
+	}
+}
+
+// Reindex forces the index to be reindexed synchronously.
+func (a *Apps) Reindex() {
+	a.asyncReindex(nil)
+}
+
+func (a *Apps) asyncReindex(then func()) {
+	a.updateMutex.Lock()
+	defer a.updateMutex.Unlock()
+
+	idx := entryIndex{}
+	forceReindex(&idx)
+	a.swap(&idx, then)
+	a.saveSnapshot(&idx)
+}
+
+// swap builds the given index and replaces the current one with it.
+func (a *Apps) swap(idx *entryIndex, then func()) {
+	idx.build(a.SortType, a.Hidden, a.Pinned, a.Extra)
+
+	a.mutex.Lock()
+
+	a.entries = *idx
+	a.Searcher.Index(a.entries.records)
+
+	if then != nil {
+		then()
+	}
+
+	onUpdate := a.onUpdate
+
+	a.mutex.Unlock()
+
+	if onUpdate != nil {
+		onUpdate()
+	}
+}
+
+func forceReindex(idx *entryIndex) {
+	files, scanErrs := desktopentry.ScanFiles(desktopentry.ApplicationDirs())
+
+	// Get the modification times before reading, so that files modified while
+	// reading are read again on the next Refresh.
+	idx.modTimes = make(map[string]time.Time, len(files))
+	for id, path := range files {
+		if s, err := os.Stat(path); err == nil {
+			idx.modTimes[id] = s.ModTime()
+		}
+	}
+
+	entries, readErrs := desktopentry.ReadEntries(files)
+
+	if errs := append(scanErrs, readErrs...); len(errs) > 0 {
+		log.Println("some applications could not be listed:", errs)
+	}
+
+	idx.files = files
+	idx.byID = make(map[string]*desktopentry.Entry, len(entries))
+
+	for _, entry := range entries {
+		idx.byID[entry.ID] = entry
+	}
+
+	idx.lastIndexed = time.Now()
+}
+
+// build builds the sorted list of results and their records from byID and the
+// extra entries. The hidden entries are left out, and the pinned entries are
+// put first.
+func (idx *entryIndex) build(sortType desktopentry.EntrySortType, hidden, pinned []string, extra []*desktopentry.Entry) {
+	isHidden := make(map[string]bool, len(hidden))
+	for _, id := range hidden {
+		isHidden[id] = true
+	}
+
+	entries := make([]*desktopentry.Entry, 0, len(idx.byID)+len(extra))
+	for _, entry := range idx.byID {
+		if !isHidden[entry.ID] {
+			entries = append(entries, entry)
+		}
+	}
+	for _, entry := range extra {
+		if !isHidden[entry.ID] {
+			entries = append(entries, entry)
+		}
+	}
+
+	if sortType == desktopentry.EntrySortedFrecency {
+		// Entries that were never launched all have the same frecency, so give
+		// them a predictable order.
+		desktopentry.Sort(entries, desktopentry.EntrySortedAlphabetically)
+	}
+
+	desktopentry.Sort(entries, sortType)
+	idx.pinned = pinFirst(entries, pinned)
+
+	idx.entries = make([]Result, len(entries))
+	idx.items = make([]Item, len(entries))
+	idx.results = make([]Result, 0, len(entries))
+
+	idx.itemIndices = make(map[string]int, len(entries))
+
+	for i, entry := range entries {
+		idx.entries[i] = Result{Entry: entry}
+		idx.items[i] = idx.entries[i].Item()
+		idx.itemIndices[entry.ID] = i
+	}
+
+	// Put actions after all entries, so entries come first if the searcher
+	// ranks them equally.
+	idx.results = append(idx.results, idx.entries...)
+	for _, entry := range entries {
+		for _, action := range entry.Actions {
+			idx.results = append(idx.results, Result{Entry: entry, Action: action})
+		}
+	}
+
+	// Extra entries have no desktop files, so they say nothing about their
+	// executables or windows.
+	applications := make([]*desktopentry.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if _, ok := idx.byID[entry.ID]; ok {
+			applications = append(applications, entry)
+		}
+	}
+
+	idx.graphical = make(map[string]bool, len(applications))
+	for _, entry := range applications {
+		if exec := entry.Executable(); exec != "" && !entry.Terminal {
+			idx.graphical[filepath.Base(exec)] = true
+		}
+	}
+
+	idx.windowClasses = make(map[string]*desktopentry.Entry, len(applications))
+	for _, entry := range applications {
+		id := strings.ToLower(strings.TrimSuffix(entry.ID, ".desktop"))
+		if _, ok := idx.windowClasses[id]; !ok {
+			idx.windowClasses[id] = entry
+		}
+	}
+	// StartupWMClass takes precedence over the IDs of other entries.
+	for _, entry := range applications {
+		if entry.StartupWMClass != "" {
+			idx.windowClasses[strings.ToLower(entry.StartupWMClass)] = entry
+		}
+	}
+
+	idx.records = make([]Record, len(idx.results))
+	for i, result := range idx.results {
+		idx.records[i] = buildRecord(result)
+	}
+}
+
+// pinFirst moves the entries with the pinned IDs to the front in the order of
+// pinned. The other entries keep their order. The IDs of the pinned entries
+// that were found are returned.
+func pinFirst(entries []*desktopentry.Entry, pinned []string) map[string]bool {
+	if len(pinned) == 0 {
+		return nil
+	}
+
+	order := make(map[string]int, len(pinned))
+	for i, id := range pinned {
+		if _, ok := order[id]; !ok {
+			order[id] = i
+		}
+	}
+
+	found := make(map[string]bool)
+	for _, entry := range entries {
+		if _, ok := order[entry.ID]; ok {
+			found[entry.ID] = true
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		oi, iPinned := order[entries[i].ID]
+		oj, jPinned := order[entries[j].ID]
+		if iPinned && jPinned {
+			return oi < oj
+		}
+		return iPinned && !jPinned
+	})
+
+	return found
+}
+
+func buildRecord(result Result) Record {
+	var record Record
+
+	if result.Action != nil {
+		// Allow both "private window" and "firefox private" to match, but
+		// rank the application itself above its actions for "firefox".
+		record[FieldName] = result.Action.Name
+		record[FieldDescription] = result.Entry.Name
+		return record
+	}
+
+	entry := result.Entry
+
+	genericNames := []string{entry.GenericName}
+	keywords := entry.Keywords
+
+	if entry.Raw != nil {
+		// Search the untranslated strings as well, since people often know
+		// applications by their English descriptions.
+		genericNames = appendMissing(genericNames, entry.Raw.String("GenericName"))
+		keywords = appendMissing(keywords, entry.Raw.Strings("Keywords")...)
+	}
+
+	categories := make([]string, len(entry.Categories))
+	for i, category := range entry.Categories {
+		categories[i] = splitCamelCase(category)
+	}
+
+	record[FieldName] = entry.Name
+	record[FieldGenericName] = strings.Join(genericNames, " ")
+	record[FieldKeywords] = strings.Join(keywords, " ")
+	record[FieldCategories] = strings.Join(categories, " ")
+	record[FieldID] = strings.TrimSuffix(entry.ID, ".desktop")
+	record[FieldDescription] = entry.Comment
+
+	if exec := entry.Executable(); exec != "" {
+		record[FieldExecutable] = filepath.Base(exec)
+	}
+
+	return record
+}
+
+// appendMissing appends the strings that aren't empty and not already in list.
+// The given list is never modified.
+func appendMissing(list []string, strs ...string) []string {
+	list = list[:len(list):len(list)]
+
+outer:
+	for _, str := range strs {
+		if str == "" {
+			continue
+		}
+		for _, existing := range list {
+			if existing == str {
+				continue outer
+			}
+		}
+		list = append(list, str)
+	}
+
+	return list
+}
+
+// splitCamelCase splits category names such as "WebBrowser" into words, so
+// that "web browser" matches them.
+func splitCamelCase(str string) string {
+	var b strings.Builder
+	b.Grow(len(str) + 4)
+
+	var prev rune
+	for _, r := range str {
+		if unicode.IsUpper(r) && unicode.IsLower(prev) {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+
+	return b.String()
+}
@@ -0,0 +1,124 @@
+package appindex
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Favorites is the list of the favorite applications of the user, which is
+// managed from the window. It is saved as a text file with one desktop ID per
+// line. All its methods are thread-safe.
+type Favorites struct {
+	path string
+
+	mutex sync.Mutex
+	ids   []string
+}
+
+// OpenFavorites opens the favorites at the given path. Favorites that do not
+// exist yet are empty. If path is empty, then the favorites are never saved.
+func OpenFavorites(path string) (*Favorites, error) {
+	f := &Favorites{path: path}
+	if path == "" {
+		return f, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return f, errors.Wrap(err, "failed to open favorites")
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			f.ids = append(f.ids, id)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return f, errors.Wrap(err, "failed to read favorites")
+	}
+
+	return f, nil
+}
+
+// IDs returns the desktop IDs of the favorites in the order that they were
+// added.
+func (f *Favorites) IDs() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return append([]string(nil), f.ids...)
+}
+
+// Has returns true if the given desktop ID is a favorite.
+func (f *Favorites) Has(id string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.index(id) != -1
+}
+
+// Toggle adds the given desktop ID to the favorites if it isn't one and removes
+// it otherwise, then saves the favorites. It returns true if the ID was added.
+func (f *Favorites) Toggle(id string) (bool, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	added := false
+
+	if i := f.index(id); i != -1 {
+		f.ids = append(f.ids[:i], f.ids[i+1:]...)
+	} else {
+		f.ids = append(f.ids, id)
+		added = true
+	}
+
+	return added, f.save()
+}
+
+func (f *Favorites) index(id string) int {
+	for i, favorite := range f.ids {
+		if favorite == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *Favorites) save() error {
+	if f.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create state directory")
+	}
+
+	tmp := f.path + ".tmp"
+
+	var data string
+	if len(f.ids) > 0 {
+		data = strings.Join(f.ids, "\n") + "\n"
+	}
+
+	if err := os.WriteFile(tmp, []byte(data), 0644); err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, "failed to write favorites")
+	}
+
+	if err := os.Rename(tmp, f.path); err != nil {
+		return errors.Wrap(err, "failed to commit favorites")
+	}
+
+	return nil
+}
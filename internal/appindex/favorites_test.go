@@ -0,0 +1,60 @@
+package appindex
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/diamondburned/gappdash/internal/desktopentry"
+)
+
+func TestFavorites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "favorites")
+
+	f, err := OpenFavorites(path)
+	if err != nil {
+		t.Fatal("cannot open new favorites:", err)
+	}
+
+	for _, id := range []string{"firefox.desktop", "foot.desktop", "gimp.desktop", "foot.desktop"} {
+		if _, err := f.Toggle(id); err != nil {
+			t.Fatalf("cannot toggle %q: %v", id, err)
+		}
+	}
+
+	if f.Has("foot.desktop") || !f.Has("gimp.desktop") {
+		t.Errorf("unexpected favorites %q", f.IDs())
+	}
+
+	f, err = OpenFavorites(path)
+	if err != nil {
+		t.Fatal("cannot reopen favorites:", err)
+	}
+
+	expected := []string{"firefox.desktop", "gimp.desktop"}
+	if ids := f.IDs(); strings.Join(ids, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %q after reopening, got %q", expected, ids)
+	}
+}
+
+func TestAppsItems(t *testing.T) {
+	apps := NewApps(NewSubstringSearcher(false, DefaultWeights))
+	apps.Hidden = []string{"avahi.desktop"}
+
+	idx := entryIndex{byID: map[string]*desktopentry.Entry{}}
+	for _, name := range []string{"Avahi", "Firefox", "Foot"} {
+		id := strings.ToLower(name) + ".desktop"
+		idx.byID[id] = &desktopentry.Entry{ID: id, Name: name}
+	}
+
+	apps.swap(&idx, nil)
+
+	var names []string
+	for _, item := range apps.Items([]string{"foot.desktop", "avahi.desktop", "missing.desktop", "firefox.desktop"}) {
+		names = append(names, item.Title)
+	}
+
+	if expected := []string{"Foot", "Firefox"}; strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %q, got %q", expected, names)
+	}
+}
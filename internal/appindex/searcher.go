@@ -1,86 +1,412 @@
 package appindex
 
 import (
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/sahilm/fuzzy"
+	"golang.org/x/text/language"
+	"golang.org/x/text/search"
 )
 
+// Field is a searchable field of a Record.
+type Field int
+
+const (
+	FieldName Field = iota
+	FieldGenericName
+	FieldKeywords
+	FieldCategories
+	FieldExecutable
+	FieldID
+	FieldDescription
+	fieldMax
+)
+
+// Record contains the searchable strings of a result, one for each field.
+// Empty fields never match.
+type Record [fieldMax]string
+
+// Weights contains the weight of each field. The score of a match in a field
+// is multiplied by the weight of the field, so matches in heavier fields rank
+// higher. Fields with no weight are not searched.
+type Weights [fieldMax]float64
+
+// DefaultWeights is the default weights, which ranks the name above
+// everything else and the description below everything else.
+var DefaultWeights = Weights{
+	FieldName:        1.0,
+	FieldGenericName: 0.6,
+	FieldKeywords:    0.5,
+	FieldCategories:  0.3,
+	FieldExecutable:  0.5,
+	FieldID:          0.4,
+	FieldDescription: 0.2,
+}
+
+// Range is a range of bytes in a string from Start to End, exclusive.
+type Range struct {
+	Start int
+	End   int
+}
+
+// FieldRanges contains the matched ranges of each field of a record. The
+// ranges of each field are sorted and never overlap.
+type FieldRanges [fieldMax][]Range
+
+// Match is a record that matched the search query.
+type Match struct {
+	// Index is the index of the record relative to the last records given to
+	// Index.
+	Index int
+	// Score is the relevance of the record. Higher is better.
+	Score float64
+	// Ranges contains the parts of the record that matched the query.
+	Ranges FieldRanges
+}
+
 // Searcher describes a search service.
 type Searcher interface {
-	// Index indexes the searcher with the given strings for indexing.
-	Index(entries []string)
-	// Search searches up the given query and returns the list of indices
-	// relative to the last entries given to Index.
-	Search(query string) []int
+	// Index indexes the searcher with the given records.
+	Index(records []Record)
+	// Search searches up the given query and returns the matched records
+	// sorted by their scores, best first. Each word in the query must match
+	// one of the fields of a record for the record to match. The returned
+	// slice is only valid until the next call to Search.
+	Search(query string) []Match
 }
 
-type fuzzySearcher struct {
-	data []string
-	ints []int
+// matcher matches single words against a list of strings. It is used for each
+// field by fieldSearcher.
+type matcher interface {
+	// index indexes the matcher with the given strings. The slice may be kept.
+	index(strs []string)
+	// match calls f with the index of each string that matches the given word,
+	// the score of the match, which is within (0, 1], and the matched ranges,
+	// which are only valid during the call.
+	match(word string, f func(i int, score float64, ranges []Range))
 }
 
-// NewFuzzySearcher creates a new fuzzy searcher. Fuzzy searching is always
-// case-insensitive.
-func NewFuzzySearcher() Searcher {
-	return &fuzzySearcher{}
+// fieldSearcher implements Searcher by matching each field separately and
+// combining the weighted scores.
+type fieldSearcher struct {
+	weights Weights
+	fields  [fieldMax]matcher
+
+	// scores contains the combined score of each record, or a negative score
+	// if a word did not match the record.
+	scores []float64
+	// best contains the best score of each record for the current word.
+	best []float64
+	// ranges contains the matched ranges of each record in every field, which
+	// may overlap.
+	ranges  []FieldRanges
+	matches []Match
+}
+
+func newFieldSearcher(weights Weights, newMatcher func() matcher) *fieldSearcher {
+	s := &fieldSearcher{weights: weights}
+	for field, weight := range weights {
+		if weight > 0 {
+			s.fields[field] = newMatcher()
+		}
+	}
+	return s
 }
 
-func (f *fuzzySearcher) Index(entries []string) { f.data = entries }
+func (s *fieldSearcher) Index(records []Record) {
+	for field, matcher := range s.fields {
+		if matcher == nil {
+			continue
+		}
+
+		strs := make([]string, len(records))
+		for i, record := range records {
+			strs[i] = record[field]
+		}
 
-func (f *fuzzySearcher) Search(query string) []int {
-	f.ints = f.ints[:0]
-	for _, match := range fuzzy.Find(query, f.data) {
-		f.ints = append(f.ints, match.Index)
+		matcher.index(strs)
 	}
 
-	return f.ints
+	s.scores = make([]float64, len(records))
+	s.best = make([]float64, len(records))
+	s.ranges = make([]FieldRanges, len(records))
 }
 
-// TODO: https://pkg.go.dev/golang.org/x/text/search
+func (s *fieldSearcher) Search(query string) []Match {
+	s.matches = s.matches[:0]
+
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return s.matches
+	}
 
-type substringSearcher struct {
-	data []string
-	ints []int
-	fold bool
+	for i := range s.scores {
+		s.scores[i] = 0
+		for field := range s.ranges[i] {
+			s.ranges[i][field] = s.ranges[i][field][:0]
+		}
+	}
+
+	for _, word := range words {
+		for i := range s.best {
+			s.best[i] = 0
+		}
+
+		for field, matcher := range s.fields {
+			if matcher == nil {
+				continue
+			}
+
+			weight := s.weights[field]
+			matcher.match(word, func(i int, score float64, ranges []Range) {
+				if score *= weight; score > s.best[i] {
+					s.best[i] = score
+				}
+				// Highlight the word in every field that it matched, not just
+				// the best one.
+				s.ranges[i][field] = append(s.ranges[i][field], ranges...)
+			})
+		}
+
+		for i, best := range s.best {
+			switch {
+			case s.scores[i] < 0:
+				// An earlier word did not match.
+			case best == 0:
+				s.scores[i] = -1
+			default:
+				s.scores[i] += best
+			}
+		}
+	}
+
+	for i, score := range s.scores {
+		if score <= 0 {
+			continue
+		}
+
+		match := Match{Index: i, Score: score}
+		for field, ranges := range s.ranges[i] {
+			if len(ranges) > 0 {
+				// Copy the ranges, since they're reused in the next search.
+				match.Ranges[field] = mergeRanges(append([]Range(nil), ranges...))
+			}
+		}
+
+		s.matches = append(s.matches, match)
+	}
+
+	// Keep records with the same score in the order that they were indexed.
+	sort.SliceStable(s.matches, func(i, j int) bool {
+		return s.matches[i].Score > s.matches[j].Score
+	})
+
+	return s.matches
 }
 
-// NewSubstringSearcher creates a new substring searcher. If caseSensitive is
-// false, then matches are done regardless of the casing.
-func NewSubstringSearcher(caseSensitive bool) Searcher {
-	return &substringSearcher{
-		fold: !caseSensitive,
+// mergeRanges sorts the given ranges and merges the ones that overlap or touch
+// in place.
+func mergeRanges(ranges []Range) []Range {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+
+	merged := ranges[:0]
+
+	for _, r := range ranges {
+		if last := len(merged) - 1; last >= 0 && r.Start <= merged[last].End {
+			if r.End > merged[last].End {
+				merged[last].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
 	}
+
+	return merged
+}
+
+// NewFuzzySearcher creates a new fuzzy searcher with the given field weights.
+// Fuzzy searching is always case-insensitive.
+func NewFuzzySearcher(weights Weights) Searcher {
+	return newFieldSearcher(weights, func() matcher { return &fuzzyMatcher{} })
+}
+
+type fuzzyMatcher struct {
+	data   []string
+	ranges []Range
 }
 
-func (s *substringSearcher) Index(data []string) {
-	if !s.fold {
-		s.data = data
+func (m *fuzzyMatcher) index(strs []string) { m.data = strs }
+
+func (m *fuzzyMatcher) match(word string, f func(int, float64, []Range)) {
+	matches := fuzzy.Find(word, m.data)
+	if len(matches) == 0 {
 		return
 	}
 
-	if cap(s.data) < len(data) {
-		s.data = make([]string, 0, len(data))
-	} else {
-		s.data = s.data[:0]
+	// The scores of the fuzzy package are unbounded, so compare them to the
+	// score of the word matching itself.
+	perfect := float64(fuzzy.Find(word, []string{word})[0].Score)
+
+	for _, match := range matches {
+		ratio := float64(match.Score) / perfect
+		if ratio < 0 {
+			ratio = 0
+		}
+		if ratio > 1 {
+			ratio = 1
+		}
+
+		// MatchedIndexes contains the byte index of each matched rune.
+		m.ranges = m.ranges[:0]
+		for _, index := range match.MatchedIndexes {
+			_, size := utf8.DecodeRuneInString(match.Str[index:])
+			m.ranges = append(m.ranges, Range{Start: index, End: index + size})
+		}
+
+		f(match.Index, 0.1+0.9*ratio, mergeRanges(m.ranges))
+	}
+}
+
+// NewSubstringSearcher creates a new substring searcher with the given field
+// weights. If caseSensitive is false, then matches are done regardless of the
+// casing.
+func NewSubstringSearcher(caseSensitive bool, weights Weights) Searcher {
+	return newFieldSearcher(weights, func() matcher {
+		return &substringMatcher{fold: !caseSensitive}
+	})
+}
+
+type substringMatcher struct {
+	data  []string
+	lower []string
+	fold  bool
+}
+
+func (m *substringMatcher) index(strs []string) {
+	m.data = strs
+	if !m.fold {
+		return
 	}
 
-	for _, str := range data {
-		s.data = append(s.data, strings.ToLower(str))
+	m.lower = make([]string, len(strs))
+	for i, str := range strs {
+		m.lower[i] = strings.ToLower(str)
 	}
 }
 
-func (s *substringSearcher) Search(query string) []int {
-	if s.fold {
-		query = strings.ToLower(query)
+func (m *substringMatcher) match(word string, f func(int, float64, []Range)) {
+	data := m.data
+	if m.fold {
+		data = m.lower
+		word = strings.ToLower(word)
+	}
+
+	for i, str := range data {
+		pos := strings.Index(str, word)
+		if pos < 0 {
+			continue
+		}
+
+		r := Range{Start: pos, End: pos + len(word)}
+		if m.fold {
+			// Lowering the case may change the length of runes, but never the
+			// number of them.
+			r.Start = runeOffset(m.data[i], utf8.RuneCountInString(str[:r.Start]))
+			r.End = runeOffset(m.data[i], utf8.RuneCountInString(str[:r.End]))
+		}
+
+		f(i, substringScore(str, word, pos), []Range{r})
 	}
+}
 
-	s.ints = s.ints[:0]
-	for i, str := range s.data {
-		if strings.Contains(str, query) {
-			s.ints = append(s.ints, i)
+// runeOffset returns the byte offset of the nth rune in str.
+func runeOffset(str string, n int) int {
+	for offset := range str {
+		if n == 0 {
+			return offset
 		}
+		n--
 	}
+	return len(str)
+}
 
-	return s.ints
+// substringScore scores the word found in str at the given position. Matches
+// at the start of str or of a word in it score higher, and so do matches that
+// cover more of str.
+func substringScore(str, word string, pos int) float64 {
+	score := 0.5
+
+	switch {
+	case pos == 0:
+		score = 1
+	case isWordStart(str, pos):
+		score = 0.8
+	}
+
+	coverage := float64(len(word)) / float64(len(str))
+	return score * (0.8 + 0.2*coverage)
+}
+
+func isWordStart(str string, pos int) bool {
+	prev, _ := utf8.DecodeLastRuneInString(str[:pos])
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+}
+
+// CollateOptions sets the differences between letters that collation-aware
+// searching ignores.
+type CollateOptions struct {
+	// IgnoreCase makes "a" match "A".
+	IgnoreCase bool
+	// IgnoreDiacritics makes "e" match "é".
+	IgnoreDiacritics bool
+	// IgnoreWidth makes "a" match the full-width "ａ".
+	IgnoreWidth bool
+}
+
+func (o CollateOptions) options() []search.Option {
+	var opts []search.Option
+	if o.IgnoreCase {
+		opts = append(opts, search.IgnoreCase)
+	}
+	if o.IgnoreDiacritics {
+		opts = append(opts, search.IgnoreDiacritics)
+	}
+	if o.IgnoreWidth {
+		opts = append(opts, search.IgnoreWidth)
+	}
+	return opts
+}
+
+// NewCollateSearcher creates a new substring searcher that compares letters
+// using the collation rules of the given language, so that, for example, "cafe"
+// can match "Café", and the Turkish dotless i is handled correctly.
+func NewCollateSearcher(lang language.Tag, opts CollateOptions, weights Weights) Searcher {
+	return newFieldSearcher(weights, func() matcher {
+		return &collateMatcher{matcher: search.New(lang, opts.options()...)}
+	})
+}
+
+type collateMatcher struct {
+	matcher *search.Matcher
+	data    []string
+}
+
+func (m *collateMatcher) index(strs []string) { m.data = strs }
+
+func (m *collateMatcher) match(word string, f func(int, float64, []Range)) {
+	pattern := m.matcher.CompileString(word)
+
+	for i, str := range m.data {
+		start, end := pattern.IndexString(str)
+		if start < 0 {
+			continue
+		}
+
+		f(i, substringScore(str, str[start:end], start), []Range{{Start: start, End: end}})
+	}
 }
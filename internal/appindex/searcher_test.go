@@ -0,0 +1,175 @@
+package appindex
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestSearchers(t *testing.T) {
+	records := []Record{
+		{
+			FieldName:        "Notes",
+			FieldDescription: "Keep track of terms and definitions",
+		},
+		{
+			FieldName:        "Terminal",
+			FieldGenericName: "Terminal Emulator",
+			FieldExecutable:  "gnome-terminal",
+		},
+		{
+			FieldName:        "New Private Window",
+			FieldDescription: "Firefox",
+		},
+		{
+			FieldName:     "Firefox",
+			FieldKeywords: "Internet WWW Browser",
+		},
+	}
+
+	searchers := map[string]Searcher{
+		"fuzzy":     NewFuzzySearcher(DefaultWeights),
+		"substring": NewSubstringSearcher(false, DefaultWeights),
+	}
+
+	tests := []struct {
+		query   string
+		indices []int
+	}{
+		{"term", []int{1, 0}},
+		{"firefox", []int{3, 2}},
+		{"firefox private", []int{2}},
+		{"browser", []int{3}},
+		{"   ", nil},
+	}
+
+	for name, searcher := range searchers {
+		searcher.Index(records)
+
+		for _, test := range tests {
+			matches := searcher.Search(test.query)
+
+			indices := make([]int, len(matches))
+			for i, match := range matches {
+				indices[i] = match.Index
+			}
+
+			if !equalInts(indices, test.indices) {
+				t.Errorf("%s: query %q: expected %v, got %v", name, test.query, test.indices, indices)
+			}
+		}
+	}
+}
+
+func TestSearcherWeights(t *testing.T) {
+	records := []Record{
+		{FieldName: "Notes", FieldDescription: "Terminal notes"},
+		{FieldName: "Terminal"},
+	}
+
+	weights := DefaultWeights
+	weights[FieldName] = 0
+
+	searcher := NewSubstringSearcher(false, weights)
+	searcher.Index(records)
+
+	matches := searcher.Search("terminal")
+	if len(matches) != 1 || matches[0].Index != 0 {
+		t.Errorf("expected only the description to be searched, got %v", matches)
+	}
+}
+
+func TestSearcherRanges(t *testing.T) {
+	records := []Record{
+		{FieldName: "Terminal", FieldDescription: "Use the command line"},
+		{FieldName: "İstanbul Guide"},
+	}
+
+	tests := []struct {
+		searcher Searcher
+		query    string
+		field    Field
+		ranges   []Range
+	}{
+		{NewSubstringSearcher(false, DefaultWeights), "term", FieldName, []Range{{0, 4}}},
+		{NewSubstringSearcher(false, DefaultWeights), "line term", FieldDescription, []Range{{16, 20}}},
+		{NewSubstringSearcher(false, DefaultWeights), "stan", FieldName, []Range{{2, 6}}},
+		{NewFuzzySearcher(DefaultWeights), "trm", FieldName, []Range{{0, 1}, {2, 4}}},
+		{NewFuzzySearcher(DefaultWeights), "ter min", FieldName, []Range{{0, 6}}},
+	}
+
+	for _, test := range tests {
+		test.searcher.Index(records)
+
+		matches := test.searcher.Search(test.query)
+		if len(matches) != 1 {
+			t.Errorf("query %q: expected 1 match, got %d", test.query, len(matches))
+			continue
+		}
+
+		if ranges := matches[0].Ranges[test.field]; !reflect.DeepEqual(ranges, test.ranges) {
+			t.Errorf("query %q: expected ranges %v, got %v", test.query, test.ranges, ranges)
+		}
+	}
+}
+
+func TestCollateSearcher(t *testing.T) {
+	records := []Record{
+		{FieldName: "Café Finder"},
+		{FieldName: "Cafeteria"},
+	}
+
+	tests := []struct {
+		opts    CollateOptions
+		query   string
+		indices []int
+	}{
+		// The shorter name is covered more by the query, so it ranks higher.
+		{CollateOptions{IgnoreCase: true, IgnoreDiacritics: true}, "cafe", []int{1, 0}},
+		{CollateOptions{IgnoreCase: true}, "cafe", []int{1}},
+		{CollateOptions{IgnoreDiacritics: true}, "cafe", nil},
+		{CollateOptions{IgnoreCase: true}, "CAFÉ", []int{0}},
+	}
+
+	for _, test := range tests {
+		searcher := NewCollateSearcher(language.French, test.opts, DefaultWeights)
+		searcher.Index(records)
+
+		matches := searcher.Search(test.query)
+
+		indices := make([]int, len(matches))
+		for i, match := range matches {
+			indices[i] = match.Index
+		}
+
+		if !equalInts(indices, test.indices) {
+			t.Errorf("%+v: query %q: expected %v, got %v", test.opts, test.query, test.indices, indices)
+		}
+	}
+
+	searcher := NewCollateSearcher(language.French, CollateOptions{IgnoreDiacritics: true}, DefaultWeights)
+	searcher.Index(records)
+
+	matches := searcher.Search("Cafe")
+	if len(matches) != 2 || matches[1].Index != 0 {
+		t.Fatalf("unexpected matches %v", matches)
+	}
+
+	// "é" is 2 bytes long, so the range is longer than the query.
+	if ranges := matches[1].Ranges[FieldName]; !reflect.DeepEqual(ranges, []Range{{0, 5}}) {
+		t.Errorf("unexpected ranges %v", ranges)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package appindex
+
+import (
+	"encoding/gob"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/diamondburned/gappdash/internal/desktopentry"
+	"github.com/pkg/errors"
+)
+
+// snapshotVersion is the version of the snapshot format. It must be bumped
+// whenever desktopentry.Entry or snapshot changes.
+const snapshotVersion = 2
+
+// snapshot is the on-disk format of the index.
+type snapshot struct {
+	Version int
+	// Locale, Desktops and AppDirs are the environment that the snapshot was
+	// made in. The snapshot is stale if they change.
+	Locale   desktopentry.Locale
+	Desktops []string
+	AppDirs  []string
+	Files    map[string]string
+	ModTimes map[string]time.Time
+	Entries  []*desktopentry.Entry
+}
+
+// LoadSnapshot loads the index from the snapshot at SnapshotPath, which is
+// much faster than Reindex. The entries may be outdated, so Refresh should be
+// called afterwards. A corrupted or outdated snapshot is deleted, and an error
+// is returned.
+func (a *Apps) LoadSnapshot() error {
+	if a.SnapshotPath == "" {
+		return errors.New("no snapshot path")
+	}
+
+	a.updateMutex.Lock()
+	defer a.updateMutex.Unlock()
+
+	f, err := os.Open(a.SnapshotPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var snap snapshot
+
+	if err := gob.NewDecoder(f).Decode(&snap); err != nil {
+		os.Remove(a.SnapshotPath)
+		return errors.Wrap(err, "corrupted snapshot")
+	}
+
+	switch {
+	case snap.Version != snapshotVersion:
+		err = errors.New("snapshot version mismatch")
+	case snap.Locale != desktopentry.CurrentLocale():
+		err = errors.New("snapshot was made in another locale")
+	case !reflect.DeepEqual(snap.Desktops, desktopentry.CurrentDesktops()):
+		// OnlyShowIn and NotShowIn depend on the desktop.
+		err = errors.New("snapshot was made in another desktop")
+	case !reflect.DeepEqual(snap.AppDirs, desktopentry.ApplicationDirs()):
+		err = errors.New("snapshot was made with other data directories")
+	}
+
+	if err != nil {
+		os.Remove(a.SnapshotPath)
+		return err
+	}
+
+	idx := entryIndex{
+		files:    snap.Files,
+		modTimes: snap.ModTimes,
+		byID:     make(map[string]*desktopentry.Entry, len(snap.Entries)),
+		// The snapshot is written right after indexing.
+		lastIndexed: s.ModTime(),
+	}
+
+	for _, entry := range snap.Entries {
+		idx.byID[entry.ID] = entry
+	}
+
+	a.swap(&idx, nil)
+	return nil
+}
+
+// saveSnapshot writes the given index to SnapshotPath if it is set.
+func (a *Apps) saveSnapshot(idx *entryIndex) {
+	if a.SnapshotPath == "" {
+		return
+	}
+
+	snap := snapshot{
+		Version:  snapshotVersion,
+		Locale:   desktopentry.CurrentLocale(),
+		Desktops: desktopentry.CurrentDesktops(),
+		AppDirs:  desktopentry.ApplicationDirs(),
+		Files:    idx.files,
+		ModTimes: idx.modTimes,
+		Entries:  make([]*desktopentry.Entry, 0, len(idx.byID)),
+	}
+
+	for _, entry := range idx.byID {
+		snap.Entries = append(snap.Entries, entry)
+	}
+
+	if err := writeSnapshot(a.SnapshotPath, &snap); err != nil {
+		log.Println("failed to save index snapshot:", err)
+	}
+}
+
+func writeSnapshot(path string, snap *snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create cache directory")
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated
+	// snapshot behind.
+	tmp := path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return errors.Wrap(err, "failed to create snapshot")
+	}
+
+	if err := gob.NewEncoder(f).Encode(snap); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return errors.Wrap(err, "failed to encode snapshot")
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, "failed to write snapshot")
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Wrap(err, "failed to commit snapshot")
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package appindex
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	t.Setenv("XDG_DATA_DIRS", filepath.Join(root, "system"))
+
+	desktopFile := filepath.Join(root, "data", "applications", "foo.desktop")
+	writeFile := func(name string, modTime time.Time) {
+		t.Helper()
+
+		content := "[Desktop Entry]\nType=Application\nExec=foo\nName=" + name + "\n"
+		if err := os.WriteFile(desktopFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(desktopFile, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(desktopFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile("Foo", time.Now().Add(-time.Hour))
+
+	hiddenFile := filepath.Join(filepath.Dir(desktopFile), "hidden.desktop")
+	hiddenTime := time.Now().Add(-time.Hour)
+	writeHidden := func(noDisplay bool) {
+		t.Helper()
+
+		content := fmt.Sprintf("[Desktop Entry]\nType=Application\nExec=hidden\nName=Hidden\nNoDisplay=%t\n", noDisplay)
+		if err := os.WriteFile(hiddenFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(hiddenFile, hiddenTime, hiddenTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeHidden(true)
+
+	snapshotPath := filepath.Join(root, "cache", "index")
+
+	idx := NewApps(NewSubstringSearcher(false, DefaultWeights))
+	idx.SnapshotPath = snapshotPath
+	idx.Reindex()
+
+	idx = NewApps(NewSubstringSearcher(false, DefaultWeights))
+	idx.SnapshotPath = snapshotPath
+
+	if err := idx.LoadSnapshot(); err != nil {
+		t.Fatal("cannot load snapshot:", err)
+	}
+
+	expectName := func(name string) {
+		t.Helper()
+
+		entries := idx.List()
+		if len(entries) != 1 || entries[0].Title != name {
+			t.Fatalf("expected only %q, got %v", name, entries)
+		}
+	}
+
+	expectName("Foo")
+
+	if results := idx.Search("foo"); len(results) != 1 {
+		t.Errorf("expected 1 search result from the snapshot, got %d", len(results))
+	}
+
+	// Refresh must pick up files changed while the snapshot was unused.
+	writeFile("Bar", time.Now())
+	idx.Refresh()
+	expectName("Bar")
+
+	if err := os.WriteFile(snapshotPath, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := idx.LoadSnapshot(); err == nil {
+		t.Fatal("corrupted snapshot loaded without errors")
+	}
+
+	if _, err := os.Stat(snapshotPath); !os.IsNotExist(err) {
+		t.Error("corrupted snapshot is not deleted")
+	}
+
+	// The index must be kept after failing to load the snapshot.
+	expectName("Bar")
+
+	// Files that aren't shown must only be read again once they're modified,
+	// so this change goes unnoticed until then.
+	writeHidden(false)
+	idx.Refresh()
+	expectName("Bar")
+
+	hiddenTime = time.Now()
+	writeHidden(false)
+	idx.Refresh()
+
+	if entries := idx.List(); len(entries) != 2 {
+		t.Errorf("expected the modified file to be shown, got %v", entries)
+	}
+}
+
+func TestSnapshotDesktops(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	t.Setenv("XDG_DATA_DIRS", filepath.Join(root, "system"))
+	t.Setenv("XDG_CURRENT_DESKTOP", "sway")
+
+	snapshotPath := filepath.Join(root, "cache", "index")
+
+	idx := NewApps(NewSubstringSearcher(false, DefaultWeights))
+	idx.SnapshotPath = snapshotPath
+	idx.Reindex()
+
+	// OnlyShowIn and NotShowIn may show other entries in another desktop.
+	t.Setenv("XDG_CURRENT_DESKTOP", "GNOME")
+
+	if err := idx.LoadSnapshot(); err == nil {
+		t.Error("snapshot of another desktop loaded without errors")
+	}
+}
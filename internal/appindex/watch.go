@@ -0,0 +1,212 @@
+package appindex
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/diamondburned/gappdash/internal/desktopentry"
+	"github.com/diamondburned/gappdash/internal/dirwatch"
+)
+
+// watchDebounce is the delay between the last change and the update. Package
+// managers usually install many files at once, so this avoids updating the
+// index for every single one.
+const watchDebounce = 250 * time.Millisecond
+
+type watcher struct {
+	*dirwatch.Watcher
+	appDirs []string
+}
+
+// Watch starts watching all applications directories for changes. Changed
+// desktop files are updated in the background without reindexing everything
+// else. MaxAge is ignored while watching. An error is returned if the
+// directories cannot be watched, in which case MaxAge is still used.
+func (a *Apps) Watch() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.watcher != nil {
+		return nil
+	}
+
+	appDirs := desktopentry.ApplicationDirs()
+
+	w, err := dirwatch.New(appDirs)
+	if err != nil {
+		return err
+	}
+
+	a.watcher = &watcher{
+		Watcher: w,
+		appDirs: appDirs,
+	}
+
+	go a.watch(a.watcher)
+	return nil
+}
+
+// Watching returns true if the index is watching for changes.
+func (a *Apps) Watching() bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.watcher != nil
+}
+
+// StopWatching stops watching for changes.
+func (a *Apps) StopWatching() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.watcher != nil {
+		a.watcher.Close()
+		a.watcher = nil
+	}
+}
+
+func (a *Apps) watch(w *watcher) {
+	changed := make(map[string]struct{})
+
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case path, ok := <-w.Events():
+			if !ok {
+				timer.Stop()
+				return
+			}
+
+			changed[path] = struct{}{}
+			timer.Reset(watchDebounce)
+
+		case <-timer.C:
+			if _, overflow := changed[dirwatch.Overflow]; overflow {
+				a.Reindex()
+			} else {
+				a.update(w.appDirs, changed, false)
+			}
+
+			changed = make(map[string]struct{})
+		}
+	}
+}
+
+// Refresh updates the entries whose desktop files were added, removed or
+// modified since they were indexed. It is much cheaper than Reindex, since
+// unchanged desktop files aren't read again.
+func (a *Apps) Refresh() {
+	a.update(desktopentry.ApplicationDirs(), nil, true)
+}
+
+// update reads the desktop files with the IDs affected by the given changed
+// paths and updates only those in the index. If checkModTimes is true, then
+// entries whose desktop files were modified since they were read are updated
+// as well, and so are files that aren't shown.
+func (a *Apps) update(appDirs []string, changed map[string]struct{}, checkModTimes bool) {
+	a.updateMutex.Lock()
+	defer a.updateMutex.Unlock()
+
+	a.mutex.Lock()
+	old := a.entries
+	a.mutex.Unlock()
+
+	// Rescanning the file names is cheap, and it catches IDs that are affected
+	// by directories being created, moved or deleted.
+	files, errs := desktopentry.ScanFiles(appDirs)
+	if len(errs) > 0 {
+		log.Println("some applications could not be listed:", errs)
+	}
+
+	affected := make(map[string]struct{})
+
+	for path := range changed {
+		for _, dir := range appDirs {
+			if id, ok := desktopentry.FileID(dir, path); ok {
+				affected[id] = struct{}{}
+			}
+		}
+	}
+
+	for id, path := range files {
+		if old.files[id] != path {
+			affected[id] = struct{}{}
+		}
+	}
+
+	for id := range old.files {
+		if _, ok := files[id]; !ok {
+			affected[id] = struct{}{}
+		}
+	}
+
+	if checkModTimes {
+		for id, path := range files {
+			modTime, ok := old.modTimes[id]
+
+			s, err := os.Stat(path)
+			if !ok || err != nil || !s.ModTime().Equal(modTime) {
+				affected[id] = struct{}{}
+			}
+		}
+	}
+
+	if len(affected) == 0 {
+		if checkModTimes {
+			// Everything was checked, so the index is as good as new.
+			a.mutex.Lock()
+			a.entries.lastIndexed = time.Now()
+			a.mutex.Unlock()
+		}
+		return
+	}
+
+	idx := entryIndex{
+		files:       files,
+		modTimes:    make(map[string]time.Time, len(files)),
+		byID:        make(map[string]*desktopentry.Entry, len(old.byID)),
+		lastIndexed: time.Now(),
+	}
+
+	for id, entry := range old.byID {
+		if _, ok := affected[id]; !ok {
+			idx.byID[id] = entry
+		}
+	}
+
+	for id, modTime := range old.modTimes {
+		if _, ok := affected[id]; !ok {
+			idx.modTimes[id] = modTime
+		}
+	}
+
+	desktops := desktopentry.CurrentDesktops()
+
+	for id := range affected {
+		path, ok := files[id]
+		if !ok {
+			continue
+		}
+
+		// Broken files are only read again once they're modified.
+		if s, err := os.Stat(path); err == nil {
+			idx.modTimes[id] = s.ModTime()
+		}
+
+		entry, err := desktopentry.ReadEntry(path, id)
+		if err != nil {
+			log.Printf("cannot update application %s: %v", path, err)
+			continue
+		}
+
+		if entry.ShouldShow(desktops) {
+			idx.byID[id] = entry
+		}
+	}
+
+	a.swap(&idx, nil)
+	a.saveSnapshot(&idx)
+}
@@ -0,0 +1,424 @@
+// Package calc provides an evaluator for arithmetic expressions.
+package calc
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// function is a function that can be called in expressions.
+type function struct {
+	arity int // -1 for at least 1 argument
+	call  func(args []float64) float64
+}
+
+func unary(f func(float64) float64) function {
+	return function{1, func(args []float64) float64 { return f(args[0]) }}
+}
+
+var functions = map[string]function{
+	"sqrt":  unary(math.Sqrt),
+	"cbrt":  unary(math.Cbrt),
+	"abs":   unary(math.Abs),
+	"sin":   unary(math.Sin),
+	"cos":   unary(math.Cos),
+	"tan":   unary(math.Tan),
+	"asin":  unary(math.Asin),
+	"acos":  unary(math.Acos),
+	"atan":  unary(math.Atan),
+	"exp":   unary(math.Exp),
+	"ln":    unary(math.Log),
+	"log":   unary(math.Log10),
+	"log2":  unary(math.Log2),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"round": unary(math.Round),
+	"pow": {2, func(args []float64) float64 {
+		return math.Pow(args[0], args[1])
+	}},
+	"min": {-1, func(args []float64) float64 {
+		min := args[0]
+		for _, arg := range args[1:] {
+			min = math.Min(min, arg)
+		}
+		return min
+	}},
+	"max": {-1, func(args []float64) float64 {
+		max := args[0]
+		for _, arg := range args[1:] {
+			max = math.Max(max, arg)
+		}
+		return max
+	}},
+}
+
+var constants = map[string]float64{
+	"pi":  math.Pi,
+	"tau": 2 * math.Pi,
+	"e":   math.E,
+}
+
+// LooksLikeMath returns true if the given string might be an expression worth
+// evaluating. It has to contain a number, and it must not be just a number, so
+// that searching for names such as "e" or "2048" does not show their values.
+func LooksLikeMath(str string) bool {
+	str = strings.TrimSpace(str)
+
+	if !strings.ContainsAny(str, "0123456789") {
+		return false
+	}
+
+	_, err := strconv.ParseFloat(str, 64)
+	return err != nil
+}
+
+// Eval evaluates the given expression. It supports the operators +, -, *, /,
+// % and ^ (or **) with the usual precedence, parentheses, the constants pi,
+// tau and e, functions such as sqrt(x), sin(x) and log(x), and hexadecimal
+// (0x), octal (0o) and binary (0b) integers.
+func Eval(expr string) (float64, error) {
+	p := parser{src: expr}
+	p.next()
+
+	v, err := p.parseExpr()
+	if err != nil {
+		return 0, err
+	}
+
+	if p.tok.kind != tokEOF {
+		return 0, p.errorf("unexpected %q", p.tok.text)
+	}
+
+	switch {
+	case math.IsNaN(v):
+		return 0, errors.New("result is not a number")
+	case math.IsInf(v, 0):
+		return 0, errors.New("result is infinite")
+	}
+
+	return v, nil
+}
+
+// Format formats the given value with up to 12 significant digits, which hides
+// rounding errors such as 0.1 + 0.2 = 0.30000000000000004.
+func Format(v float64) string {
+	v, _ = strconv.ParseFloat(strconv.FormatFloat(v, 'g', 12, 64), 64)
+
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type tokenKind uint8
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp // + - * / % ^ ** ( ) ,
+	tokInvalid
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+func (p *parser) errorf(f string, v ...interface{}) error {
+	return fmt.Errorf("at %d: %s", p.tok.pos, fmt.Sprintf(f, v...))
+}
+
+// next reads the next token into p.tok.
+func (p *parser) next() {
+	for p.pos < len(p.src) && isSpace(p.src[p.pos]) {
+		p.pos++
+	}
+
+	start := p.pos
+
+	if p.pos >= len(p.src) {
+		p.tok = token{tokEOF, "", start}
+		return
+	}
+
+	c := p.src[p.pos]
+
+	switch {
+	case isDigit(c) || c == '.':
+		for p.pos < len(p.src) && (isAlnum(p.src[p.pos]) || p.src[p.pos] == '.') {
+			// Allow signs in exponents, such as 1e-5.
+			if (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') && p.pos+1 < len(p.src) &&
+				(p.src[p.pos+1] == '-' || p.src[p.pos+1] == '+') && !isPrefixed(p.src[start:]) {
+				p.pos++
+			}
+			p.pos++
+		}
+		p.tok = token{tokNumber, p.src[start:p.pos], start}
+
+	case isLetter(c):
+		for p.pos < len(p.src) && isAlnum(p.src[p.pos]) {
+			p.pos++
+		}
+		p.tok = token{tokIdent, p.src[start:p.pos], start}
+
+	case strings.HasPrefix(p.src[p.pos:], "**"):
+		p.pos += 2
+		p.tok = token{tokOp, "^", start}
+
+	case strings.IndexByte("+-*/%^(),", c) >= 0:
+		p.pos++
+		p.tok = token{tokOp, p.src[start:p.pos], start}
+
+	default:
+		p.pos = len(p.src)
+		p.tok = token{tokInvalid, p.src[start:], start}
+	}
+}
+
+func (p *parser) isOp(ops string) bool {
+	return p.tok.kind == tokOp && strings.Contains(ops, p.tok.text)
+}
+
+// parseExpr parses addition and subtraction, which have the lowest precedence.
+func (p *parser) parseExpr() (float64, error) {
+	v, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+
+	for p.isOp("+-") {
+		op := p.tok.text
+		p.next()
+
+		rhs, err := p.parseTerm()
+		if err != nil {
+			return 0, err
+		}
+
+		if op == "+" {
+			v += rhs
+		} else {
+			v -= rhs
+		}
+	}
+
+	return v, nil
+}
+
+func (p *parser) parseTerm() (float64, error) {
+	v, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+
+	for p.isOp("*/%") {
+		op := p.tok.text
+		p.next()
+
+		rhs, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+
+		switch op {
+		case "*":
+			v *= rhs
+		case "/":
+			if rhs == 0 {
+				return 0, errors.New("division by zero")
+			}
+			v /= rhs
+		case "%":
+			if rhs == 0 {
+				return 0, errors.New("division by zero")
+			}
+			v = math.Mod(v, rhs)
+		}
+	}
+
+	return v, nil
+}
+
+// parseUnary parses signs. They bind looser than powers, so -2^2 is -4.
+func (p *parser) parseUnary() (float64, error) {
+	if p.isOp("+-") {
+		op := p.tok.text
+		p.next()
+
+		v, err := p.parseUnary()
+		if op == "-" {
+			v = -v
+		}
+		return v, err
+	}
+
+	return p.parsePower()
+}
+
+// parsePower parses powers, which are right-associative, so 2^3^2 is 2^9.
+func (p *parser) parsePower() (float64, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return 0, err
+	}
+
+	if !p.isOp("^") {
+		return base, nil
+	}
+
+	p.next()
+
+	exp, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Pow(base, exp), nil
+}
+
+func (p *parser) parsePrimary() (float64, error) {
+	tok := p.tok
+
+	switch tok.kind {
+	case tokNumber:
+		p.next()
+		return parseNumber(tok.text)
+
+	case tokIdent:
+		p.next()
+		name := strings.ToLower(tok.text)
+
+		if !p.isOp("(") {
+			if v, ok := constants[name]; ok {
+				return v, nil
+			}
+			return 0, fmt.Errorf("at %d: unknown constant %q", tok.pos, tok.text)
+		}
+
+		fn, ok := functions[name]
+		if !ok {
+			return 0, fmt.Errorf("at %d: unknown function %q", tok.pos, tok.text)
+		}
+
+		p.next()
+
+		args, err := p.parseArgs()
+		if err != nil {
+			return 0, err
+		}
+
+		if fn.arity == -1 && len(args) == 0 || fn.arity >= 0 && len(args) != fn.arity {
+			return 0, fmt.Errorf("at %d: wrong number of arguments to %s", tok.pos, name)
+		}
+
+		return fn.call(args), nil
+
+	case tokOp:
+		if tok.text != "(" {
+			break
+		}
+
+		p.next()
+
+		v, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+
+		if !p.isOp(")") {
+			return 0, p.errorf("missing )")
+		}
+
+		p.next()
+		return v, nil
+
+	case tokEOF:
+		return 0, p.errorf("unexpected end")
+	}
+
+	return 0, p.errorf("unexpected %q", tok.text)
+}
+
+// parseArgs parses the arguments of a function after the opening parenthesis
+// up to and including the closing one.
+func (p *parser) parseArgs() ([]float64, error) {
+	var args []float64
+
+	if p.isOp(")") {
+		p.next()
+		return args, nil
+	}
+
+	for {
+		v, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, v)
+
+		switch {
+		case p.isOp(","):
+			p.next()
+		case p.isOp(")"):
+			p.next()
+			return args, nil
+		default:
+			return nil, p.errorf("missing )")
+		}
+	}
+}
+
+func parseNumber(text string) (float64, error) {
+	if isPrefixed(text) {
+		i, err := strconv.ParseUint(text[2:], prefixBase(text[1]), 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid number %q", text)
+		}
+		return float64(i), nil
+	}
+
+	v, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", text)
+	}
+
+	return v, nil
+}
+
+// isPrefixed returns true if the number has a base prefix such as 0x.
+func isPrefixed(text string) bool {
+	return len(text) > 2 && text[0] == '0' && prefixBase(text[1]) != 0
+}
+
+func prefixBase(c byte) int {
+	switch unicode.ToLower(rune(c)) {
+	case 'x':
+		return 16
+	case 'o':
+		return 8
+	case 'b':
+		return 2
+	default:
+		return 0
+	}
+}
+
+func isSpace(c byte) bool  { return c == ' ' || c == '\t' }
+func isDigit(c byte) bool  { return '0' <= c && c <= '9' }
+func isLetter(c byte) bool { return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' }
+func isAlnum(c byte) bool  { return isDigit(c) || isLetter(c) }
@@ -0,0 +1,125 @@
+package calc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	tests := map[string]float64{
+		"1 + 2 * 3":       7,
+		"(1 + 2) * 3":     9,
+		"2^10 * 3.5":      3584,
+		"2 ** 10":         1024,
+		"(4+5)/3":         3,
+		"0x1f + 7":        38,
+		"0o17":            15,
+		"0b1010 - 0B10":   8,
+		"-2^2":            -4,
+		"2^3^2":           512,
+		"2^-1":            0.5,
+		"10 % 4":          2,
+		"1.5e3 + 1e-3":    1500.001,
+		"sqrt(16) + 1":    5,
+		"sin(pi / 2)":     1,
+		"log(1000)":       3,
+		"ln(e)":           1,
+		"max(1, 5, 3)":    5,
+		"pow(2, 0.5)^2":   2,
+		"--3":             3,
+		"  .5 + .25  ":    0.75,
+		"ROUND(2.5) * PI": 3 * math.Pi,
+	}
+
+	for expr, expected := range tests {
+		v, err := Eval(expr)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", expr, err)
+			continue
+		}
+
+		if math.Abs(v-expected) > 1e-9 {
+			t.Errorf("%q: expected %v, got %v", expr, expected, v)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	exprs := []string{
+		"",
+		"firefox",
+		"1 +",
+		"(1 + 2",
+		"1 / 0",
+		"sqrt(-1)",
+		"foo(1)",
+		"sqrt(1, 2)",
+		"max()",
+		"0xzz",
+		"2 3",
+		"7zip",
+		"1 $ 2",
+	}
+
+	for _, expr := range exprs {
+		if v, err := Eval(expr); err == nil {
+			t.Errorf("%q: expected error, got %v", expr, v)
+		}
+	}
+}
+
+func TestLooksLikeMath(t *testing.T) {
+	tests := map[string]bool{
+		"1+1":     true,
+		"sqrt(2)": true,
+		"0x1f":    true,
+		"2048":    false,
+		" 3.5 ":   false,
+		"e":       false,
+		"pi * e":  false,
+	}
+
+	for str, expected := range tests {
+		if LooksLikeMath(str) != expected {
+			t.Errorf("%q: expected %v", str, expected)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := map[float64]string{
+		0.1 + 0.2:   "0.3",
+		1024:        "1024",
+		-3.5:        "-3.5",
+		1e20:        "1e+20",
+		1.0 / 3.0:   "0.333333333333",
+		math.Sqrt2:  "1.41421356237",
+		123456789.0: "123456789",
+	}
+
+	for v, expected := range tests {
+		if str := Format(v); str != expected {
+			t.Errorf("%v: expected %q, got %q", v, expected, str)
+		}
+	}
+}
+
+func TestProvider(t *testing.T) {
+	var copied string
+	p := NewProvider(func(value string) { copied = value })
+
+	if items := p.Search("firefox"); len(items) != 0 {
+		t.Errorf("unexpected items for a name: %v", items)
+	}
+
+	items := p.Search("2 + 3 * 4")
+	if len(items) != 1 || items[0].Title != "14" {
+		t.Fatalf("expected the value, got %v", items)
+	}
+
+	items[0].Activate()
+
+	if copied != "14" {
+		t.Errorf("expected the value to be copied, got %q", copied)
+	}
+}
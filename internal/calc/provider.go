@@ -0,0 +1,39 @@
+package calc
+
+import "github.com/diamondburned/gappdash/internal/appindex"
+
+// Provider is the appindex.Provider that shows the value of the query if it is
+// an arithmetic expression.
+type Provider struct {
+	copy func(value string)
+}
+
+// NewProvider creates a new calculator provider. copy copies the value of an
+// activated item to the clipboard.
+func NewProvider(copy func(value string)) *Provider {
+	return &Provider{copy: copy}
+}
+
+// Search implements appindex.Provider.
+func (p *Provider) Search(query string) []appindex.Item {
+	if !LooksLikeMath(query) {
+		return nil
+	}
+
+	v, err := Eval(query)
+	if err != nil {
+		return nil
+	}
+
+	value := Format(v)
+
+	return []appindex.Item{{
+		ID:       "calc",
+		Title:    value,
+		Subtitle: query + " (copy to clipboard)",
+		Icon:     "accessories-calculator",
+		Score:    appindex.TopScore,
+		Class:    "calculator",
+		Activate: func() { p.copy(value) },
+	}}
+}
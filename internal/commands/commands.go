@@ -0,0 +1,193 @@
+// Package commands provides the command mode, which runs arbitrary commands
+// through the shell of the user.
+package commands
+
+import (
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+	"github.com/diamondburned/gappdash/internal/desktopentry"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxHistoryItems    = 20
+	maxCompletionItems = 20
+)
+
+// Runner runs commands through a shell.
+type Runner struct {
+	// Shell is the shell that runs commands with -c. If empty, then $SHELL is
+	// used, or /bin/sh if that is unset.
+	Shell string
+	// Terminal is the command of the terminal emulator that commands are run
+	// in, such as ["foot"] or ["alacritty", "-e"]. The shell and its
+	// arguments are appended to it. If it is empty, then the terminal that
+	// desktopentry.FindTerminal finds is used, like for applications.
+	Terminal []string
+}
+
+func (r Runner) shell() string {
+	if r.Shell != "" {
+		return r.Shell
+	}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+func (r Runner) terminal() []string {
+	if len(r.Terminal) > 0 {
+		return r.Terminal
+	}
+	return desktopentry.FindTerminal()
+}
+
+// Args returns the arguments that run the given command, which are the shell
+// and its arguments, prefixed with the terminal if inTerminal is true. An error
+// is returned if there is no terminal to run the command in.
+func (r Runner) Args(command string, inTerminal bool) ([]string, error) {
+	args := []string{r.shell(), "-c", command}
+
+	if inTerminal {
+		terminal := r.terminal()
+		if len(terminal) == 0 {
+			return nil, errors.New("no terminal found, set $TERMINAL or providers.commands.terminal")
+		}
+		args = append(append([]string(nil), terminal...), args...)
+	}
+
+	return args, nil
+}
+
+// Run starts the given command in the background.
+func (r Runner) Run(command string, inTerminal bool) error {
+	args, err := r.Args(command, inTerminal)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	if home, err := os.UserHomeDir(); err == nil {
+		cmd.Dir = home
+	}
+
+	if err := cmd.Start(); err != nil {
+		return errors.Wrapf(err, "failed to run %q", args[0])
+	}
+
+	// Reap the process once it exits.
+	go cmd.Wait()
+	return nil
+}
+
+// Provider is the appindex.PrefixProvider of the command mode. It offers to
+// run the query, along with matching commands from the history and the names
+// of matching executables.
+type Provider struct {
+	runner      Runner
+	prefix      string
+	history     *History
+	executables func() []string
+}
+
+// NewProvider creates a new command mode provider that is used for queries
+// that start with the given prefix. The history and the executables function
+// are optional.
+func NewProvider(prefix string, runner Runner, history *History, executables func() []string) *Provider {
+	return &Provider{
+		runner:      runner,
+		prefix:      prefix,
+		history:     history,
+		executables: executables,
+	}
+}
+
+// Prefix implements appindex.PrefixProvider.
+func (p *Provider) Prefix() string {
+	return p.prefix
+}
+
+// Search implements appindex.Provider. The query must not have the prefix.
+func (p *Provider) Search(query string) []appindex.Item {
+	command := strings.TrimSpace(query)
+
+	var items []appindex.Item
+
+	if command != "" {
+		items = append(items, p.commandItem(command, "Run command"))
+	}
+
+	if p.history != nil {
+		history := p.history.Search(command)
+		if len(history) > maxHistoryItems {
+			history = history[:maxHistoryItems]
+		}
+
+		for _, old := range history {
+			if old != command {
+				items = append(items, p.commandItem(old, "From history"))
+			}
+		}
+	}
+
+	// Only complete the first word, since that is the executable.
+	if command != "" && !strings.ContainsAny(command, " \t") && p.executables != nil {
+		var completions int
+
+		for _, name := range p.executables() {
+			if name == command || !strings.HasPrefix(name, command) {
+				continue
+			}
+
+			item := p.commandItem(name, "Executable")
+			item.Completion = p.prefix + name + " "
+			items = append(items, item)
+
+			if completions++; completions == maxCompletionItems {
+				break
+			}
+		}
+	}
+
+	return items
+}
+
+func (p *Provider) commandItem(command, subtitle string) appindex.Item {
+	run := func(inTerminal bool) func() {
+		return func() { p.run(command, inTerminal) }
+	}
+
+	return appindex.Item{
+		ID:         command,
+		Title:      command,
+		Subtitle:   subtitle,
+		Icon:       "utilities-terminal",
+		Class:      "command",
+		Activate:   run(false),
+		Completion: p.prefix + command,
+		Actions: func() []appindex.Item {
+			return []appindex.Item{
+				{ID: command, Title: "Run", Activate: run(false)},
+				{ID: command, Title: "Run in Terminal", Activate: run(true)},
+			}
+		},
+	}
+}
+
+func (p *Provider) run(command string, inTerminal bool) {
+	if err := p.runner.Run(command, inTerminal); err != nil {
+		log.Println("cannot run command:", err)
+		return
+	}
+
+	if p.history != nil {
+		if err := p.history.Add(command); err != nil {
+			log.Println("cannot save command history:", err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commands")
+
+	h, err := OpenHistory(path, 3)
+	if err != nil {
+		t.Fatal("cannot open new history:", err)
+	}
+
+	for _, command := range []string{"ls", "make", "ls", "git status", "  ", "htop"} {
+		if err := h.Add(command); err != nil {
+			t.Fatalf("cannot add %q: %v", command, err)
+		}
+	}
+
+	expected := []string{"htop", "git status", "ls"}
+	if commands := h.Search(""); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected %q, got %q", expected, commands)
+	}
+
+	h, err = OpenHistory(path, 3)
+	if err != nil {
+		t.Fatal("cannot reopen history:", err)
+	}
+
+	if commands := h.Search("t"); !reflect.DeepEqual(commands, []string{"htop", "git status"}) {
+		t.Errorf("unexpected commands %q after reopening", commands)
+	}
+}
+
+func TestRunnerArgs(t *testing.T) {
+	runner := Runner{Shell: "/bin/bash"}
+
+	if args, err := runner.Args("echo hi", false); err != nil || !reflect.DeepEqual(args, []string{"/bin/bash", "-c", "echo hi"}) {
+		t.Errorf("unexpected args without terminal %q: %v", args, err)
+	}
+
+	// Without a terminal, commands must not silently run detached.
+	t.Setenv("TERMINAL", "")
+	t.Setenv("PATH", "")
+
+	if args, err := runner.Args("echo hi", true); err == nil {
+		t.Errorf("expected an error without any terminal, got %q", args)
+	}
+
+	t.Setenv("TERMINAL", "my-terminal")
+
+	expected := []string{"my-terminal", "-e", "/bin/bash", "-c", "echo hi"}
+	if args, err := runner.Args("echo hi", true); err != nil || !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected the terminal of $TERMINAL %q, got %q: %v", expected, args, err)
+	}
+
+	runner.Terminal = []string{"alacritty", "-e"}
+
+	expected = []string{"alacritty", "-e", "/bin/bash", "-c", "echo hi"}
+	if args, err := runner.Args("echo hi", true); err != nil || !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %q, got %q: %v", expected, args, err)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	history, _ := OpenHistory("", 0)
+	history.Add("make test")
+	history.Add("htop")
+
+	executables := func() []string {
+		return []string{"mail", "make", "makepkg", "man"}
+	}
+
+	p := NewProvider(">", Runner{}, history, executables)
+
+	tests := []struct {
+		query       string
+		titles      []string
+		completions []string
+	}{
+		{"", []string{"htop", "make test"}, []string{">htop", ">make test"}},
+		{" mak", []string{"mak", "make test", "make", "makepkg"}, []string{">mak", ">make test", ">make ", ">makepkg "}},
+		{"make", []string{"make", "make test", "makepkg"}, []string{">make", ">make test", ">makepkg "}},
+		{"make te", []string{"make te", "make test"}, []string{">make te", ">make test"}},
+	}
+
+	for _, test := range tests {
+		items := p.Search(test.query)
+
+		var titles, completions []string
+		for _, item := range items {
+			titles = append(titles, item.Title)
+			completions = append(completions, item.Completion)
+		}
+
+		if !reflect.DeepEqual(titles, test.titles) {
+			t.Errorf("query %q: expected titles %q, got %q", test.query, test.titles, titles)
+		}
+		if !reflect.DeepEqual(completions, test.completions) {
+			t.Errorf("query %q: expected completions %q, got %q", test.query, test.completions, completions)
+		}
+	}
+}
+
+func TestExecutablesProvider(t *testing.T) {
+	executables := func() []string {
+		return []string{"Xorg", "firefox", "fish", "git", "gitk", "legit"}
+	}
+	isGraphical := func(name string) bool {
+		return name == "firefox" || name == "gitk"
+	}
+
+	p := NewExecutablesProvider(Runner{Terminal: []string{"foot"}}, executables, isGraphical)
+
+	var titles, subtitles []string
+	for _, item := range p.Search("Gi") {
+		titles = append(titles, item.Title)
+		subtitles = append(subtitles, item.Subtitle)
+	}
+
+	if expected := []string{"git", "gitk", "legit"}; !reflect.DeepEqual(titles, expected) {
+		t.Errorf("expected titles %q, got %q", expected, titles)
+	}
+	if expected := []string{"Command-line tool", "Executable", "Command-line tool"}; !reflect.DeepEqual(subtitles, expected) {
+		t.Errorf("expected subtitles %q, got %q", expected, subtitles)
+	}
+
+	if items := p.Search("xorg"); len(items) != 1 || items[0].Score >= 0 {
+		t.Errorf("unexpected items for xorg: %+v", items)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"git":         "git",
+		"x86_64-gcc":  "x86_64-gcc",
+		"my program":  "'my program'",
+		"it's":        `'it'\''s'`,
+		"$(rm -rf ~)": "'$(rm -rf ~)'",
+	}
+
+	for in, expected := range tests {
+		if out := quote(in); out != expected {
+			t.Errorf("quote(%q): expected %q, got %q", in, expected, out)
+		}
+	}
+}
+
+func TestDesktopEntries(t *testing.T) {
+	entries := []Entry{
+		{Name: "Deploy Staging", Command: "~/bin/deploy staging", Keywords: []string{"release"}},
+		{Name: "VPN", Comment: "Connect to the office", Command: "nmcli connection up office", Icon: "network-vpn"},
+		{Name: "Top", Command: "htop", Terminal: true},
+	}
+
+	desktopEntries := DesktopEntries(entries, Runner{Shell: "/bin/sh"})
+
+	desktop := desktopEntries[0]
+	if args, err := desktop.ExecArgs(); err != nil || !reflect.DeepEqual(args, []string{"/bin/sh", "-c", "~/bin/deploy staging"}) {
+		t.Errorf("unexpected arguments %q (%v)", args, err)
+	}
+	if desktop.ID != "custom:Deploy Staging" || desktop.Icon != "application-x-executable" {
+		t.Errorf("unexpected desktop entry %+v", desktop)
+	}
+
+	// Without a terminal of the runner, the one of desktopentry is used.
+	// The command can be found by its executable.
+	if vpn := desktopEntries[1]; !reflect.DeepEqual(vpn.Keywords, []string{"nmcli"}) || vpn.Icon != "network-vpn" {
+		t.Errorf("unexpected desktop entry %+v", vpn)
+	}
+
+	if top := desktopEntries[2]; !top.Terminal {
+		t.Errorf("expected Top to run in a terminal")
+	}
+
+	terminal := Entry{Name: "Top", Command: "htop", Terminal: true}.DesktopEntry(Runner{Shell: "bash", Terminal: []string{"foot"}})
+	if args, _ := terminal.ExecArgs(); terminal.Terminal || !reflect.DeepEqual(args, []string{"foot", "bash", "-c", "htop"}) {
+		t.Errorf("expected the terminal of the runner, got %q", args)
+	}
+}
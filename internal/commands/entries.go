@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/diamondburned/gappdash/internal/desktopentry"
+)
+
+// Entry is a user-defined entry that runs a command, like an application
+// without a desktop file.
+type Entry struct {
+	Name    string
+	Comment string
+	// Icon is a GIcon string. If empty, then a generic icon is used.
+	Icon string
+	// Command is run through the shell.
+	Command  string
+	Keywords []string
+	// Terminal is true if the command is run in the terminal of the Runner.
+	Terminal bool
+}
+
+// DesktopEntry returns the entry as a desktop entry that runs the command with
+// the given runner, so that it can be listed and searched along with the
+// applications in appindex.Apps.Extra. Its ID is the name prefixed with
+// "custom:". If the runner has no terminal, then a terminal entry runs in the
+// one that desktopentry finds, like terminal applications.
+func (e Entry) DesktopEntry(runner Runner) *desktopentry.Entry {
+	args := []string{runner.shell(), "-c", e.Command}
+
+	terminal := e.Terminal
+	if terminal && len(runner.Terminal) > 0 {
+		args = append(append([]string(nil), runner.Terminal...), args...)
+		terminal = false
+	}
+
+	icon := e.Icon
+	if icon == "" {
+		icon = "application-x-executable"
+	}
+
+	// The executable is the shell, so allow the command to be found by its
+	// own executable.
+	keywords := append([]string(nil), e.Keywords...)
+	if fields := strings.Fields(e.Command); len(fields) > 0 {
+		keywords = append(keywords, filepath.Base(fields[0]))
+	}
+
+	return &desktopentry.Entry{
+		ID:       "custom:" + e.Name,
+		Type:     "Application",
+		Name:     e.Name,
+		Comment:  e.Comment,
+		Icon:     icon,
+		Exec:     desktopentry.QuoteExec(args),
+		Terminal: terminal,
+		Keywords: keywords,
+	}
+}
+
+// DesktopEntries returns the given entries as desktop entries. See
+// Entry.DesktopEntry.
+func DesktopEntries(entries []Entry, runner Runner) []*desktopentry.Entry {
+	desktopEntries := make([]*desktopentry.Entry, len(entries))
+	for i, entry := range entries {
+		desktopEntries[i] = entry.DesktopEntry(runner)
+	}
+	return desktopEntries
+}
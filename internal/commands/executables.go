@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+)
+
+const maxExecutableItems = 50
+
+// ExecutablesProvider is the appindex.Provider of the executables in $PATH,
+// which replaces dmenu_run. Its items rank below the applications. Command-line
+// tools are run in the terminal if one is configured.
+type ExecutablesProvider struct {
+	runner      Runner
+	executables func() []string
+	isGraphical func(name string) bool
+}
+
+// NewExecutablesProvider creates a new provider of the names returned by
+// executables. isGraphical reports whether an executable is a graphical
+// program, which is never run in the terminal. It is optional, in which case
+// every executable is considered a command-line tool.
+func NewExecutablesProvider(runner Runner, executables func() []string, isGraphical func(string) bool) *ExecutablesProvider {
+	return &ExecutablesProvider{
+		runner:      runner,
+		executables: executables,
+		isGraphical: isGraphical,
+	}
+}
+
+// Search implements appindex.Provider. Executables whose names contain the
+// query are returned, the ones that start with it first.
+func (p *ExecutablesProvider) Search(query string) []appindex.Item {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" || strings.ContainsAny(query, " \t") {
+		return nil
+	}
+
+	var items []appindex.Item
+
+	for _, name := range p.executables() {
+		lower := strings.ToLower(name)
+
+		i := strings.Index(lower, query)
+		if i == -1 {
+			continue
+		}
+
+		item := p.item(name)
+		item.Score = appindex.BottomScore + executableScore(i, len(query), len(name))
+
+		// Lowering may change the length of some characters.
+		if len(lower) == len(name) {
+			item.TitleRanges = []appindex.Range{{Start: i, End: i + len(query)}}
+		}
+
+		items = append(items, item)
+	}
+
+	// Executables are sorted by name, so equally ranked ones stay that way.
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Score > items[j].Score
+	})
+
+	if len(items) > maxExecutableItems {
+		items = items[:maxExecutableItems]
+	}
+
+	return items
+}
+
+// executableScore scores a match of the given length at the given offset of a
+// name. It is below 1, and matches at the start that cover more of the name
+// score higher.
+func executableScore(offset, length, nameLength int) float64 {
+	coverage := float64(length) / float64(nameLength)
+	if offset == 0 {
+		return 0.5 + 0.4*coverage
+	}
+	return 0.4 * coverage
+}
+
+func (p *ExecutablesProvider) item(name string) appindex.Item {
+	run := func(inTerminal bool) func() {
+		return func() { p.run(name, inTerminal) }
+	}
+
+	inTerminal := p.isGraphical == nil || !p.isGraphical(name)
+
+	item := appindex.Item{
+		ID:       name,
+		Title:    name,
+		Subtitle: "Executable",
+		Icon:     "application-x-executable",
+		Class:    "executable",
+		Activate: run(inTerminal),
+	}
+
+	if inTerminal {
+		item.Subtitle = "Command-line tool"
+		item.Icon = "utilities-terminal"
+	}
+
+	item.Actions = func() []appindex.Item {
+		return []appindex.Item{
+			{ID: name, Title: "Run", Activate: run(false)},
+			{ID: name, Title: "Run in Terminal", Activate: run(true)},
+		}
+	}
+
+	return item
+}
+
+func (p *ExecutablesProvider) run(name string, inTerminal bool) {
+	if err := p.runner.Run(quote(name), inTerminal); err != nil {
+		log.Println("cannot run executable:", err)
+	}
+}
+
+// quote quotes the given string for the shell if needed.
+func quote(str string) string {
+	safe := str != "" && strings.IndexFunc(str, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("-_.+,:@%/=", r))
+	}) == -1
+
+	if safe {
+		return str
+	}
+
+	return "'" + strings.ReplaceAll(str, "'", `'\''`) + "'"
+}
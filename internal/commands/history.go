@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// History is the history of run commands. It is saved as a text file with one
+// command per line, oldest first. All its methods are thread-safe.
+type History struct {
+	path string
+	max  int
+
+	mutex    sync.Mutex
+	commands []string
+}
+
+// OpenHistory opens the history at the given path, keeping at most max
+// commands. A history that does not exist yet is empty.
+func OpenHistory(path string, max int) (*History, error) {
+	h := &History{path: path, max: max}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return h, errors.Wrap(err, "failed to open command history")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.commands = append(h.commands, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return h, errors.Wrap(err, "failed to read command history")
+	}
+
+	h.trim()
+	return h, nil
+}
+
+// Add adds the command to the history as the most recent one and saves the
+// history. An older identical command is removed.
+func (h *History) Add(command string) error {
+	command = strings.TrimSpace(command)
+	if command == "" || strings.Contains(command, "\n") {
+		return nil
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for i, old := range h.commands {
+		if old == command {
+			h.commands = append(h.commands[:i], h.commands[i+1:]...)
+			break
+		}
+	}
+
+	h.commands = append(h.commands, command)
+	h.trim()
+
+	return h.save()
+}
+
+// Search returns the commands that contain the given query, most recent first.
+// An empty query returns every command.
+func (h *History) Search(query string) []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var commands []string
+
+	for i := len(h.commands) - 1; i >= 0; i-- {
+		if strings.Contains(h.commands[i], query) {
+			commands = append(commands, h.commands[i])
+		}
+	}
+
+	return commands
+}
+
+func (h *History) trim() {
+	if h.max > 0 && len(h.commands) > h.max {
+		h.commands = h.commands[len(h.commands)-h.max:]
+	}
+}
+
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create state directory")
+	}
+
+	// Commands may contain secrets, so keep the file private.
+	tmp := h.path + ".tmp"
+	data := strings.Join(h.commands, "\n") + "\n"
+
+	if err := os.WriteFile(tmp, []byte(data), 0600); err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, "failed to write command history")
+	}
+
+	if err := os.Rename(tmp, h.path); err != nil {
+		return errors.Wrap(err, "failed to commit command history")
+	}
+
+	return nil
+}
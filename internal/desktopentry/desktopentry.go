@@ -1,16 +1,17 @@
+// Package desktopentry implements parsing, listing and launching of desktop
+// entries according to the XDG Desktop Entry Specification.
 package desktopentry
 
 import (
-	"context"
 	"io/fs"
 	"log"
 	"os"
 	"os/exec"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/diamondburned/gappdash/internal/sortutil"
-	"github.com/diamondburned/gotk4/pkg/gio/v2"
 )
 
 // EntrySortType describes possible types to sort entries by.
@@ -24,13 +25,18 @@ const (
 	EntrySortedAlphabeticallyReverse
 	EntrySortedModTime // exec
 	EntrySortedModTimeReverse
+	// EntrySortedFrecency sorts entries by how frequently and how recently
+	// they were launched according to UserHistory. The most used entries are
+	// put first. Ties are sorted alphabetically.
+	EntrySortedFrecency
 	entrySortedMax
 )
 
 type entrySorter struct {
-	entries  []gio.AppInfor
+	entries  []*Entry
 	names    []string
 	stats    []fs.FileInfo
+	scores   []float64
 	sortType EntrySortType
 }
 
@@ -48,6 +54,10 @@ func (s *entrySorter) Swap(i, j int) {
 	if s.stats != nil {
 		s.stats[i], s.stats[j] = s.stats[j], s.stats[i]
 	}
+
+	if s.scores != nil {
+		s.scores[i], s.scores[j] = s.scores[j], s.scores[i]
+	}
 }
 
 func (s *entrySorter) Less(i, j int) bool {
@@ -60,6 +70,13 @@ func (s *entrySorter) Less(i, j int) bool {
 		return s.modTime(i).Before(s.modTime(j))
 	case EntrySortedModTimeReverse:
 		return s.modTime(i).After(s.modTime(j))
+	case EntrySortedFrecency:
+		if s.scores[i] != s.scores[j] {
+			return s.scores[i] > s.scores[j]
+		}
+		// Entries that were never launched all have the same score, so give
+		// them a predictable order.
+		return sortutil.LessFold(s.names[i], s.names[j])
 	default:
 		return false
 	}
@@ -72,70 +89,212 @@ func (s *entrySorter) modTime(i int) time.Time {
 	return s.stats[i].ModTime()
 }
 
-// List searches the given paths for all valid desktop files. Invalid desktop
-// files are ignored. Files that cannot be opened will be ignored as well, but
-// the returned errors will be of type ListErrors.
-func List(sortBy EntrySortType) []gio.AppInfor {
-	apps := gio.AppInfoGetAll()
-
-	filtered := apps[:0]
-
-	for _, app := range apps {
-		if app.ShouldShow() {
-			filtered = append(filtered, app)
-		}
-	}
+// List lists all desktop entries in ApplicationDirs that should be shown in
+// the current desktop. Desktop files that are not applications or that are
+// hidden are ignored. Files that cannot be read or parsed will be ignored as
+// well, but the returned error will be of type ListErrors. The returned
+// entries are always valid, even if there's an error.
+func List(sortBy EntrySortType) ([]*Entry, error) {
+	files, scanErrs := ScanFiles(ApplicationDirs())
+	entries, readErrs := ReadEntries(files)
 
-	apps = filtered
+	Sort(entries, sortBy)
+	return entries, append(scanErrs, readErrs...).errorOrNil()
+}
 
+// Sort sorts the given entries in place. The sort is stable, so entries that
+// are equal keep their order. Nothing is done if sortBy is EntryUnsorted.
+func Sort(entries []*Entry, sortBy EntrySortType) {
 	if sortBy <= EntryUnsorted || sortBy >= entrySortedMax {
-		return apps
+		return
 	}
 
 	var names []string
 	var stats []fs.FileInfo
+	var scores []float64
 
 	switch sortBy {
 	case EntrySortedAlphabetically, EntrySortedAlphabeticallyReverse:
-		names = make([]string, len(apps))
+		names = make([]string, len(entries))
 
-		for i, app := range apps {
-			names[i] = app.DisplayName()
+		for i, entry := range entries {
+			names[i] = entry.Name
 		}
 
 	case EntrySortedModTime, EntrySortedModTimeReverse:
-		stats = make([]fs.FileInfo, len(apps))
+		stats = make([]fs.FileInfo, len(entries))
+
+		for i, entry := range entries {
+			path, err := lookPath(entry.Executable())
+			if err != nil {
+				continue
+			}
 
-		for i, app := range apps {
-			s, err := os.Stat(app.Executable())
+			s, err := os.Stat(path)
 			if err == nil {
 				stats[i] = s
 			}
 		}
+
+	case EntrySortedFrecency:
+		names = make([]string, len(entries))
+		scores = make([]float64, len(entries))
+
+		history := UserHistory()
+		now := time.Now()
+
+		for i, entry := range entries {
+			names[i] = entry.Name
+			scores[i] = history.Frecency(entry.ID, now)
+		}
 	}
 
-	sort.Sort(&entrySorter{
-		entries:  apps,
+	sort.Stable(&entrySorter{
+		entries:  entries,
 		names:    names,
 		stats:    stats,
+		scores:   scores,
 		sortType: sortBy,
 	})
+}
 
-	return apps
+// Launcher launches the application with the given desktop file ID, or its
+// action with the given ID if action is not empty. known is false if the
+// launcher does not know the application.
+type Launcher func(id, action string) (known bool, err error)
+
+var launcher struct {
+	mutex  sync.Mutex
+	launch Launcher
 }
 
-// Exec launches the given desktop entry.
-func Exec(entry gio.AppInfor) {
-	entry.LaunchURIsAsync(context.Background(), nil, nil, func(result gio.AsyncResulter) {
-		if err := entry.LaunchURIsFinish(result); err != nil {
-			log.Println("failed to launch normally:", err)
+// SetLauncher sets the Launcher that Exec and ExecAction try first, such as
+// one that supports D-Bus activation and startup notification. The Exec key is
+// run directly if it is nil, which is the default, or if it does not know the
+// entry.
+func SetLauncher(launch Launcher) {
+	launcher.mutex.Lock()
+	launcher.launch = launch
+	launcher.mutex.Unlock()
+}
 
-			exe := entry.Executable()
-			log.Println("trying with os/exec for executable", exe)
+func launch(id, action string) (known bool, err error) {
+	launcher.mutex.Lock()
+	launch := launcher.launch
+	launcher.mutex.Unlock()
 
-			if err := exec.Command(exe).Start(); err != nil {
-				log.Println("os/exec failed:", err)
-			}
+	if launch == nil || id == "" {
+		return false, nil
+	}
+	return launch(id, action)
+}
+
+// Exec launches the given desktop entry. The launch is recorded into
+// UserHistory. The Launcher is used to launch the entry if it knows it,
+// otherwise the Exec key is run directly.
+func Exec(entry *Entry) {
+	UserHistory().Record(entry.ID)
+
+	known, err := launch(entry.ID, "")
+	if !known {
+		execDirectly(entry)
+		return
+	}
+
+	if err != nil {
+		log.Println("failed to launch normally:", err)
+		log.Println("trying with os/exec for entry", entry.ID)
+		execDirectly(entry)
+	}
+}
+
+// ExecAction launches the given action of the desktop entry. The launch is
+// recorded into UserHistory as a launch of the entry. Like Exec, the Launcher
+// is used to launch the action if it knows the entry.
+func ExecAction(entry *Entry, action *Action) {
+	UserHistory().Record(entry.ID)
+
+	if known, _ := launch(entry.ID, action.ID); known {
+		return
+	}
+
+	args, err := entry.ActionExecArgs(action)
+	if err != nil {
+		log.Printf("invalid Exec key for action %s of %s: %v", action.ID, entry.ID, err)
+		return
+	}
+
+	startEntryCommand(entry, args)
+}
+
+func execDirectly(entry *Entry) {
+	args, err := entry.ExecArgs()
+	if err != nil {
+		log.Println("invalid Exec key:", err)
+		return
+	}
+
+	startEntryCommand(entry, args)
+}
+
+// startEntryCommand starts the command of the entry in its working directory,
+// in a terminal if the entry needs one.
+func startEntryCommand(entry *Entry, args []string) {
+	if entry.Terminal {
+		terminal := FindTerminal()
+		if terminal == nil {
+			log.Println("no terminal found to run", entry.ID)
+			return
 		}
-	})
+		args = append(append([]string(nil), terminal...), args...)
+	}
+
+	startCommand(args, entry.WorkingDir)
+}
+
+// terminals are the terminal emulators that FindTerminal looks for, along with
+// the arguments before the command to run. They mostly follow the list that GIO
+// looks for.
+var terminals = [][]string{
+	{"x-terminal-emulator", "-e"},
+	{"gnome-terminal", "--"},
+	{"mate-terminal", "-x"},
+	{"xfce4-terminal", "-x"},
+	{"tilix", "-e"},
+	{"konsole", "-e"},
+	{"alacritty", "-e"},
+	{"foot"},
+	{"kitty"},
+	{"xterm", "-e"},
+}
+
+// FindTerminal returns the command that runs the arguments appended to it in
+// a terminal emulator, or nil if none is found. $TERMINAL is preferred, and it
+// is assumed to take the command after -e. The returned slice must not be
+// modified.
+func FindTerminal() []string {
+	if terminal := os.Getenv("TERMINAL"); terminal != "" {
+		return []string{terminal, "-e"}
+	}
+
+	for _, terminal := range terminals {
+		if _, err := exec.LookPath(terminal[0]); err == nil {
+			return terminal
+		}
+	}
+
+	return nil
+}
+
+func startCommand(args []string, dir string) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+
+	if err := cmd.Start(); err != nil {
+		log.Println("os/exec failed:", err)
+		return
+	}
+
+	// Reap the process once it exits.
+	go cmd.Wait()
 }
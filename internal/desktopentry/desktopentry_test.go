@@ -1,15 +1,30 @@
 package desktopentry
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestListDesktopEntries(t *testing.T) {
-	entries := List(EntrySortedAlphabetically)
+	entries, err := List(EntrySortedAlphabetically)
+	if err != nil {
+		t.Log("some entries could not be listed:", err)
+	}
+
 	if len(entries) == 0 {
 		t.Fatal("no entries found")
 	}
 
 	for _, entry := range entries {
-		t.Logf("got entry %s (%s)", entry.DisplayName(), entry.Executable())
+		t.Logf("got entry %s (%s)", entry.Name, entry.Executable())
+	}
+}
+
+func TestFindTerminal(t *testing.T) {
+	t.Setenv("TERMINAL", "my-terminal")
+
+	if terminal := FindTerminal(); !reflect.DeepEqual(terminal, []string{"my-terminal", "-e"}) {
+		t.Errorf("expected $TERMINAL, got %q", terminal)
 	}
 }
 
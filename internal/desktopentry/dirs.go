@@ -0,0 +1,168 @@
+package desktopentry
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/diamondburned/gappdash/internal/fsutil"
+)
+
+// DataDirs returns the XDG data directories in the order of precedence, which
+// is $XDG_DATA_HOME followed by $XDG_DATA_DIRS.
+func DataDirs() []string {
+	var dirs []string
+
+	if home := os.Getenv("XDG_DATA_HOME"); home != "" {
+		dirs = append(dirs, home)
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".local", "share"))
+	}
+
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+
+	for _, dir := range strings.Split(dataDirs, ":") {
+		// The specification requires paths to be absolute.
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+// ApplicationDirs returns the applications directory of each directory in
+// DataDirs. The directories may not exist.
+func ApplicationDirs() []string {
+	dirs := DataDirs()
+	for i, dir := range dirs {
+		dirs[i] = filepath.Join(dir, "applications")
+	}
+	return dirs
+}
+
+// FileID returns the desktop file ID of the file at the given path inside the
+// given applications directory. The ID is the relative path with slashes
+// replaced by dashes, so applications/kde4/foo.desktop has the ID
+// kde4-foo.desktop. False is returned if the path is not a desktop file inside
+// the directory.
+func FileID(appDir, path string) (string, bool) {
+	if !strings.HasSuffix(path, ".desktop") {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(appDir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+
+	return strings.ReplaceAll(filepath.ToSlash(rel), "/", "-"), true
+}
+
+// ScanFiles walks the given applications directories and returns the path of
+// the desktop file for each desktop file ID. If multiple directories have a
+// file with the same ID, then the one in the earliest directory is used.
+// Directories that don't exist are skipped, and links to directories are
+// followed.
+func ScanFiles(appDirs []string) (map[string]string, ListErrors) {
+	var errs ListErrors
+	files := make(map[string]string)
+
+	for _, appDir := range appDirs {
+		fsutil.WalkDir(appDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if !os.IsNotExist(err) {
+					errs = append(errs, &ListError{Path: path, Err: err})
+				}
+				return nil
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			id, ok := FileID(appDir, path)
+			if !ok {
+				return nil
+			}
+
+			if _, ok := files[id]; !ok {
+				files[id] = path
+			}
+
+			return nil
+		})
+	}
+
+	return files, errs
+}
+
+// ReadEntries reads the given desktop files, which map each ID to its path,
+// and returns the entries that should be shown in the current desktop. Files
+// that cannot be read or parsed are skipped, and their errors are returned.
+func ReadEntries(files map[string]string) ([]*Entry, ListErrors) {
+	var errs ListErrors
+	desktops := CurrentDesktops()
+	entries := make([]*Entry, 0, len(files))
+
+	for id, path := range files {
+		entry, err := ReadEntry(path, id)
+		if err != nil {
+			errs = append(errs, &ListError{Path: path, Err: err})
+			continue
+		}
+
+		// An entry that shouldn't be shown still overrides entries of the same
+		// ID in later directories, which is why it's filtered only here.
+		if entry.ShouldShow(desktops) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, errs
+}
+
+// ListError is the error for a single desktop file that could not be read.
+type ListError struct {
+	Path string
+	Err  error
+}
+
+// Error implements error.
+func (err *ListError) Error() string {
+	return err.Path + ": " + err.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err *ListError) Unwrap() error {
+	return err.Err
+}
+
+// ListErrors is a list of errors for desktop files that could not be read.
+type ListErrors []*ListError
+
+// Error implements error. It only describes the first error.
+func (errs ListErrors) Error() string {
+	switch len(errs) {
+	case 0:
+		return "no errors"
+	case 1:
+		return errs[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", errs[0], len(errs)-1)
+	}
+}
+
+// errorOrNil returns nil if the list is empty. It prevents returning a non-nil
+// error interface holding an empty list.
+func (errs ListErrors) errorOrNil() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
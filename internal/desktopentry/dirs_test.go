@@ -0,0 +1,90 @@
+package desktopentry
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func writeDesktopFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanFiles(t *testing.T) {
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	system := filepath.Join(root, "system")
+
+	t.Setenv("XDG_DATA_HOME", home)
+	t.Setenv("XDG_DATA_DIRS", system+":relative/ignored")
+	t.Setenv("XDG_CURRENT_DESKTOP", "sway")
+
+	writeDesktopFile(t, filepath.Join(system, "applications", "foo.desktop"),
+		"[Desktop Entry]\nType=Application\nName=Foo\nExec=foo\n")
+	writeDesktopFile(t, filepath.Join(system, "applications", "kde4", "bar.desktop"),
+		"[Desktop Entry]\nType=Application\nName=Bar\nExec=bar\n")
+	writeDesktopFile(t, filepath.Join(system, "applications", "hidden.desktop"),
+		"[Desktop Entry]\nType=Application\nName=Hidden\nExec=hidden\n")
+	writeDesktopFile(t, filepath.Join(system, "applications", "broken.desktop"),
+		"Name=Broken\n")
+
+	// Nix profiles link to the directories of their packages, and links may
+	// loop.
+	writeDesktopFile(t, filepath.Join(root, "store", "baz", "baz.desktop"),
+		"[Desktop Entry]\nType=Application\nName=Baz\nExec=baz\n")
+	symlink(t, filepath.Join(root, "store", "baz"), filepath.Join(system, "applications", "nix"))
+	symlink(t, filepath.Join(system, "applications"), filepath.Join(root, "store", "baz", "loop"))
+
+	// Override foo.desktop and hide hidden.desktop from the user's directory.
+	writeDesktopFile(t, filepath.Join(home, "applications", "foo.desktop"),
+		"[Desktop Entry]\nType=Application\nName=User Foo\nExec=foo --user\n")
+	writeDesktopFile(t, filepath.Join(home, "applications", "hidden.desktop"),
+		"[Desktop Entry]\nHidden=true\n")
+
+	dirs := ApplicationDirs()
+	if len(dirs) != 2 {
+		t.Fatalf("expected 2 application dirs, got %q", dirs)
+	}
+
+	files, errs := ScanFiles(dirs)
+	if len(errs) > 0 {
+		t.Fatal("unexpected scan errors:", errs)
+	}
+
+	if path := files["foo.desktop"]; path != filepath.Join(home, "applications", "foo.desktop") {
+		t.Errorf("foo.desktop is not overridden, got %q", path)
+	}
+
+	entries, errs := ReadEntries(files)
+	if len(errs) != 1 || errs[0].Path != files["broken.desktop"] {
+		t.Errorf("expected 1 error for broken.desktop, got %v", errs)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.ID+"="+entry.Name)
+	}
+	sort.Strings(names)
+
+	expected := []string{"foo.desktop=User Foo", "kde4-bar.desktop=Bar", "nix-baz.desktop=Baz"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected entries %q, got %q", expected, names)
+	}
+}
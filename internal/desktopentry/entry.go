@@ -0,0 +1,381 @@
+package desktopentry
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Action is an additional action of an application, which is declared in a
+// [Desktop Action <id>] group. It is usually shown in a context menu.
+type Action struct {
+	ID   string
+	Name string
+	Icon string
+	Exec string
+}
+
+// Entry is a typed desktop entry parsed from the [Desktop Entry] group of a
+// desktop file. Localized keys are already localized to the current locale.
+type Entry struct {
+	// ID is the desktop file ID, which is the path of the file relative to its
+	// applications directory with slashes replaced by dashes.
+	ID string
+	// Path is the path to the desktop file.
+	Path string
+	// ModTime is the modification time of the desktop file.
+	ModTime time.Time
+
+	Type            string
+	Name            string
+	GenericName     string
+	Comment         string
+	Icon            string
+	Exec            string
+	TryExec         string
+	WorkingDir      string // Path key
+	Terminal        bool
+	NoDisplay       bool
+	Hidden          bool
+	DBusActivatable bool
+	OnlyShowIn      []string
+	NotShowIn       []string
+	Categories      []string
+	Keywords        []string
+	MimeTypes       []string
+	StartupWMClass  string
+	Actions         []*Action
+
+	// Raw is the raw [Desktop Entry] group. It contains every key, including
+	// the untranslated values of the localized keys above.
+	Raw *Group
+}
+
+const desktopEntryGroup = "Desktop Entry"
+
+// MainCategories are the main categories of the specification that entries are
+// grouped by. Audio and Video are grouped into AudioVideo, while the rest are
+// grouped into OtherCategory.
+var MainCategories = []string{
+	"AudioVideo",
+	"Development",
+	"Graphics",
+	"Network",
+	"Office",
+	"Settings",
+	"System",
+	"Utility",
+	"Game",
+}
+
+// OtherCategory is the main category of entries without one of the
+// MainCategories.
+const OtherCategory = "Other"
+
+// MainCategory returns the first of the MainCategories in the categories of the
+// entry, or OtherCategory if there is none.
+func (e *Entry) MainCategory() string {
+	for _, category := range e.Categories {
+		switch category {
+		case "Audio", "Video":
+			return "AudioVideo"
+		}
+
+		for _, main := range MainCategories {
+			if category == main {
+				return main
+			}
+		}
+	}
+
+	return OtherCategory
+}
+
+// NewEntry creates a typed entry from the given parsed file. The locale is
+// used to localize the localized keys. ID, Path and ModTime are left empty.
+func NewEntry(file *File, locale Locale) (*Entry, error) {
+	group := file.Group(desktopEntryGroup)
+	if group == nil {
+		return nil, errors.New("missing [Desktop Entry] group")
+	}
+
+	if len(file.Groups) == 0 || file.Groups[0] != group {
+		return nil, errors.New("[Desktop Entry] is not the first group")
+	}
+
+	entry := Entry{
+		Type:            group.String("Type"),
+		Name:            group.LocaleString("Name", locale),
+		GenericName:     group.LocaleString("GenericName", locale),
+		Comment:         group.LocaleString("Comment", locale),
+		Icon:            group.LocaleString("Icon", locale),
+		Exec:            group.String("Exec"),
+		TryExec:         group.String("TryExec"),
+		WorkingDir:      group.String("Path"),
+		Terminal:        group.Bool("Terminal"),
+		NoDisplay:       group.Bool("NoDisplay"),
+		Hidden:          group.Bool("Hidden"),
+		DBusActivatable: group.Bool("DBusActivatable"),
+		OnlyShowIn:      group.Strings("OnlyShowIn"),
+		NotShowIn:       group.Strings("NotShowIn"),
+		Categories:      group.Strings("Categories"),
+		Keywords:        group.LocaleStrings("Keywords", locale),
+		MimeTypes:       group.Strings("MimeType"),
+		StartupWMClass:  group.String("StartupWMClass"),
+		Raw:             group,
+	}
+
+	for _, id := range group.Strings("Actions") {
+		// Actions without a group are ignored as the specification says.
+		actionGroup := file.Group("Desktop Action " + id)
+		if actionGroup == nil {
+			continue
+		}
+
+		action := Action{
+			ID:   id,
+			Name: actionGroup.LocaleString("Name", locale),
+			Icon: actionGroup.LocaleString("Icon", locale),
+			Exec: actionGroup.String("Exec"),
+		}
+
+		// Actions without Exec are only activatable over D-Bus, which isn't
+		// supported.
+		if action.Name != "" && action.Exec != "" {
+			entry.Actions = append(entry.Actions, &action)
+		}
+	}
+
+	// Hidden entries are treated as deleted, so don't bother validating them.
+	if entry.Hidden {
+		return &entry, nil
+	}
+
+	if entry.Type == "" {
+		return nil, errors.New("missing Type key")
+	}
+	if entry.Name == "" {
+		return nil, errors.New("missing Name key")
+	}
+	if entry.Type == "Application" && entry.Exec == "" && !entry.DBusActivatable {
+		return nil, errors.New("missing Exec key")
+	}
+
+	return &entry, nil
+}
+
+// ReadEntry reads and parses the desktop file at the given path using
+// CurrentLocale. The returned entry has the given desktop file ID.
+func ReadEntry(path, id string) (*Entry, error) {
+	s, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := NewEntry(file, CurrentLocale())
+	if err != nil {
+		return nil, err
+	}
+
+	entry.ID = id
+	entry.Path = path
+	entry.ModTime = s.ModTime()
+
+	return entry, nil
+}
+
+// ShouldShow returns true if the entry is an application that should be shown
+// in desktops with the given names, which are usually from CurrentDesktops.
+func (e *Entry) ShouldShow(desktops []string) bool {
+	if e.Type != "Application" || e.Hidden || e.NoDisplay {
+		return false
+	}
+
+	if len(e.OnlyShowIn) > 0 && !containsAny(e.OnlyShowIn, desktops) {
+		return false
+	}
+
+	if containsAny(e.NotShowIn, desktops) {
+		return false
+	}
+
+	if e.TryExec != "" {
+		if _, err := lookPath(e.TryExec); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CurrentDesktops returns the names of the current desktop environment from
+// $XDG_CURRENT_DESKTOP.
+func CurrentDesktops() []string {
+	if desktop := os.Getenv("XDG_CURRENT_DESKTOP"); desktop != "" {
+		return strings.Split(desktop, ":")
+	}
+	return nil
+}
+
+func containsAny(list, values []string) bool {
+	for _, v := range values {
+		for _, item := range list {
+			if item == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func lookPath(file string) (string, error) {
+	if filepath.IsAbs(file) {
+		s, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		if s.IsDir() || s.Mode()&0111 == 0 {
+			return "", errors.New("not an executable")
+		}
+		return file, nil
+	}
+	return exec.LookPath(file)
+}
+
+// Executable returns the program of the Exec key. It returns an empty string
+// if the Exec key is invalid.
+func (e *Entry) Executable() string {
+	args, err := e.ExecArgs()
+	if err != nil || len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// ExecArgs splits the Exec key into the program and its arguments. Field codes
+// are expanded as if no files or URLs are given.
+func (e *Entry) ExecArgs() ([]string, error) {
+	return e.execArgs(e.Exec)
+}
+
+// ActionExecArgs is like ExecArgs, but for the Exec key of the given action.
+func (e *Entry) ActionExecArgs(action *Action) ([]string, error) {
+	return e.execArgs(action.Exec)
+}
+
+func (e *Entry) execArgs(exec string) ([]string, error) {
+	args, err := splitExec(exec)
+	if err != nil {
+		return nil, err
+	}
+
+	expanded := args[:0]
+	replacer := strings.NewReplacer(
+		"%c", e.Name,
+		"%k", e.Path,
+		"%%", "%",
+	)
+
+	for _, arg := range args {
+		switch arg {
+		case "%f", "%F", "%u", "%U", "%d", "%D", "%n", "%N", "%v", "%m":
+			// File and URL codes expand to nothing without files, and
+			// deprecated codes are removed.
+			continue
+		case "%i":
+			if e.Icon != "" {
+				expanded = append(expanded, "--icon", e.Icon)
+			}
+			continue
+		}
+
+		expanded = append(expanded, replacer.Replace(arg))
+	}
+
+	if len(expanded) == 0 {
+		return nil, errors.New("empty Exec key")
+	}
+
+	return expanded, nil
+}
+
+// QuoteExec returns the Exec value that runs the given arguments. Every
+// argument is quoted, and percent signs are escaped, so that ExecArgs returns
+// the arguments unchanged.
+func QuoteExec(args []string) string {
+	replacer := strings.NewReplacer(
+		`"`, `\"`,
+		"`", "\\`",
+		"$", `\$`,
+		`\`, `\\`,
+		"%", "%%",
+	)
+
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = `"` + replacer.Replace(arg) + `"`
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// splitExec splits an unescaped Exec value into arguments. Arguments may be
+// quoted in double quotes, inside of which ", `, $ and \ must be escaped with
+// a backslash.
+func splitExec(value string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var inArg, quoted bool
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		switch {
+		case quoted && c == '\\':
+			if i+1 == len(value) {
+				return nil, errors.New("unterminated escape in Exec")
+			}
+			i++
+			arg.WriteByte(value[i])
+		case quoted && c == '"':
+			quoted = false
+		case quoted:
+			arg.WriteByte(c)
+		case c == '"':
+			quoted = true
+			inArg = true
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if quoted {
+		return nil, errors.New("unterminated quote in Exec")
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	if len(args) == 0 {
+		return nil, errors.New("empty Exec key")
+	}
+
+	return args, nil
+}
@@ -0,0 +1,218 @@
+package desktopentry
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// historyVersion is the version of the history file format. Files with a
+// different version are ignored.
+const historyVersion = 1
+
+// maxHistorySamples is the maximum number of launch times kept per entry. The
+// frecency score is calculated from these samples.
+const maxHistorySamples = 10
+
+// History is a persistent launch history of desktop entries. It is used to
+// rank entries by frecency, which is the launch frequency weighted by how
+// recent the launches were. All its methods are thread-safe.
+type History struct {
+	path    string
+	mutex   sync.Mutex
+	entries map[string]*historyEntry
+}
+
+type historyFile struct {
+	Version int                      `json:"version"`
+	Entries map[string]*historyEntry `json:"entries"`
+}
+
+type historyEntry struct {
+	// Count is the total number of launches.
+	Count int `json:"count"`
+	// Launches contains the last few launch times, oldest first.
+	Launches []time.Time `json:"launches"`
+}
+
+// OpenHistory opens the history file at the given path. If the file does not
+// exist, then an empty history is returned, and the file will be created on
+// the first Record.
+func OpenHistory(path string) (*History, error) {
+	h := &History{
+		path:    path,
+		entries: make(map[string]*historyEntry),
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return h, errors.Wrap(err, "failed to read history")
+	}
+
+	var file historyFile
+	if err := json.Unmarshal(b, &file); err != nil {
+		return h, errors.Wrap(err, "failed to decode history")
+	}
+
+	if file.Version == historyVersion && file.Entries != nil {
+		h.entries = file.Entries
+	}
+
+	return h, nil
+}
+
+var userHistory struct {
+	once    sync.Once
+	history *History
+}
+
+// UserHistory returns the launch history stored in the user's state
+// directory, which is $XDG_STATE_HOME/gappdash/history.json. The history is
+// only loaded once.
+func UserHistory() *History {
+	userHistory.once.Do(func() {
+		path, err := StateFile("history.json")
+		if err != nil {
+			log.Println("cannot locate launch history:", err)
+			// Use an in-memory history that's never saved.
+			userHistory.history = &History{entries: make(map[string]*historyEntry)}
+			return
+		}
+
+		h, err := OpenHistory(path)
+		if err != nil {
+			log.Println("ignoring launch history:", err)
+		}
+
+		userHistory.history = h
+	})
+
+	return userHistory.history
+}
+
+// StateFile returns the path of the given file in the state directory of
+// gappdash, which is $XDG_STATE_HOME/gappdash or ~/.local/state/gappdash. The
+// directory may not exist yet.
+func StateFile(filename string) (string, error) {
+	state := os.Getenv("XDG_STATE_HOME")
+	if state == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get home directory")
+		}
+		state = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(state, "gappdash", filename), nil
+}
+
+// Record records a launch of the entry with the given desktop ID and saves the
+// history.
+func (h *History) Record(id string) {
+	h.RecordAt(id, time.Now())
+}
+
+// RecordAt records a launch of the entry with the given desktop ID at the
+// given time and saves the history.
+func (h *History) RecordAt(id string, t time.Time) {
+	if id == "" {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	entry, ok := h.entries[id]
+	if !ok {
+		entry = &historyEntry{}
+		h.entries[id] = entry
+	}
+
+	entry.Count++
+	entry.Launches = append(entry.Launches, t)
+	if len(entry.Launches) > maxHistorySamples {
+		entry.Launches = entry.Launches[len(entry.Launches)-maxHistorySamples:]
+	}
+
+	if err := h.save(); err != nil {
+		log.Println("failed to save launch history:", err)
+	}
+}
+
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(historyFile{
+		Version: historyVersion,
+		Entries: h.entries,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode history")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create state directory")
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated
+	// history behind.
+	tmp := h.path + ".tmp"
+
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return errors.Wrap(err, "failed to write history")
+	}
+
+	if err := os.Rename(tmp, h.path); err != nil {
+		return errors.Wrap(err, "failed to commit history")
+	}
+
+	return nil
+}
+
+// Frecency returns the frecency score of the entry with the given desktop ID
+// relative to now. Entries that were never launched have a score of 0.
+func (h *History) Frecency(id string, now time.Time) float64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	entry, ok := h.entries[id]
+	if !ok || len(entry.Launches) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, launch := range entry.Launches {
+		total += recencyWeight(now.Sub(launch))
+	}
+
+	// Scale the average recency weight of the samples by the total number of
+	// launches, so old but frequently used entries still rank well.
+	return float64(entry.Count) * total / float64(len(entry.Launches))
+}
+
+func recencyWeight(age time.Duration) float64 {
+	const day = 24 * time.Hour
+
+	switch {
+	case age < 4*day:
+		return 100
+	case age < 14*day:
+		return 70
+	case age < 31*day:
+		return 50
+	case age < 90*day:
+		return 30
+	default:
+		return 10
+	}
+}
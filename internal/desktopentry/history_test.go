@@ -0,0 +1,46 @@
+package desktopentry
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryFrecency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	h, err := OpenHistory(path)
+	if err != nil {
+		t.Fatal("cannot open empty history:", err)
+	}
+
+	now := time.Now()
+	old := now.Add(-100 * 24 * time.Hour)
+
+	// Launched often, but a long time ago.
+	for i := 0; i < 5; i++ {
+		h.RecordAt("old.desktop", old)
+	}
+	// Launched a few times recently.
+	for i := 0; i < 3; i++ {
+		h.RecordAt("recent.desktop", now)
+	}
+
+	recent := h.Frecency("recent.desktop", now)
+	if frecency := h.Frecency("old.desktop", now); frecency >= recent {
+		t.Errorf("old entry has frecency %f >= recent entry's %f", frecency, recent)
+	}
+	if frecency := h.Frecency("never.desktop", now); frecency != 0 {
+		t.Errorf("unlaunched entry has non-zero frecency %f", frecency)
+	}
+
+	// The history must survive reopening.
+	h, err = OpenHistory(path)
+	if err != nil {
+		t.Fatal("cannot reopen history:", err)
+	}
+
+	if frecency := h.Frecency("recent.desktop", now); frecency != recent {
+		t.Errorf("reopened history has frecency %f, expected %f", frecency, recent)
+	}
+}
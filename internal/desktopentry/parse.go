@@ -0,0 +1,359 @@
+package desktopentry
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+// File is a parsed desktop file as described by the Desktop Entry
+// Specification. It is a list of groups of key-value pairs.
+type File struct {
+	// Groups contains the groups in the order that they appear in the file.
+	Groups []*Group
+}
+
+// Group returns the group with the given name, or nil if there's none.
+func (f *File) Group(name string) *Group {
+	for _, group := range f.Groups {
+		if group.Name == name {
+			return group
+		}
+	}
+	return nil
+}
+
+// Group is a group of key-value pairs, such as [Desktop Entry]. The values are
+// kept as they appear in the file; use the getter methods to unescape them.
+type Group struct {
+	Name string
+	// Keys maps each key, including localized keys such as Name[de], to its
+	// raw value.
+	Keys map[string]string
+}
+
+// Has returns true if the group has the given key.
+func (g *Group) Has(key string) bool {
+	_, ok := g.Keys[key]
+	return ok
+}
+
+// String returns the unescaped string value of the given key.
+func (g *Group) String(key string) string {
+	return unescape(g.Keys[key])
+}
+
+// Bool returns the boolean value of the given key. Values other than "true"
+// and "1" are false.
+func (g *Group) Bool(key string) bool {
+	switch g.Keys[key] {
+	case "true", "1":
+		return true
+	default:
+		return false
+	}
+}
+
+// Strings returns the unescaped list of strings of the given key. Elements are
+// separated by semicolons.
+func (g *Group) Strings(key string) []string {
+	return splitList(g.Keys[key])
+}
+
+// LocaleString returns the unescaped value of the given localized key for the
+// given locale. The untranslated value is returned if there's no value for the
+// locale.
+func (g *Group) LocaleString(key string, locale Locale) string {
+	return unescape(g.Keys[g.localeKey(key, locale)])
+}
+
+// LocaleStrings is the list version of LocaleString.
+func (g *Group) LocaleStrings(key string, locale Locale) []string {
+	return splitList(g.Keys[g.localeKey(key, locale)])
+}
+
+func (g *Group) localeKey(key string, locale Locale) string {
+	for _, suffix := range locale.candidates() {
+		localized := key + "[" + suffix + "]"
+		if _, ok := g.Keys[localized]; ok {
+			return localized
+		}
+	}
+	return key
+}
+
+// Parse parses a desktop file from the given reader.
+func Parse(r io.Reader) (*File, error) {
+	var file File
+	var group *Group
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if !utf8.ValidString(line) {
+			return nil, fmt.Errorf("line %d: invalid UTF-8", n)
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated group header", n)
+			}
+
+			name := line[1 : len(line)-1]
+			if !validGroupName(name) {
+				return nil, fmt.Errorf("line %d: invalid group name %q", n, name)
+			}
+			if file.Group(name) != nil {
+				return nil, fmt.Errorf("line %d: duplicate group %q", n, name)
+			}
+
+			group = &Group{Name: name, Keys: make(map[string]string)}
+			file.Groups = append(file.Groups, group)
+			continue
+		}
+
+		if group == nil {
+			return nil, fmt.Errorf("line %d: key outside of a group", n)
+		}
+
+		key, value, ok := cutString(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key=value", n)
+		}
+
+		key = strings.TrimSpace(key)
+		if !validKey(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", n, key)
+		}
+
+		// Later keys override earlier ones, which is what GLib does.
+		group.Keys[key] = strings.TrimSpace(value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+// ParseFile parses the desktop file at the given path.
+func ParseFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file, err := Parse(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse")
+	}
+
+	return file, nil
+}
+
+func validGroupName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		if r == '[' || r == ']' || r < 0x20 || r == 0x7F {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validKey(key string) bool {
+	name := key
+
+	// Allow a locale suffix, such as Name[de_DE@euro].
+	if i := strings.IndexByte(key, '['); i != -1 {
+		if !strings.HasSuffix(key, "]") || i == len(key)-2 {
+			return false
+		}
+		name = key[:i]
+	}
+
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		switch {
+		case r >= 'A' && r <= 'Z':
+		case r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9':
+		case r == '-':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// unescape unescapes a string value. Unknown escape sequences are kept as-is.
+func unescape(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var b strings.Builder
+	b.Grow(len(value))
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			b.WriteByte(value[i])
+			continue
+		}
+
+		i++
+		switch value[i] {
+		case 's':
+			b.WriteByte(' ')
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '\\':
+			b.WriteByte('\\')
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(value[i])
+		}
+	}
+
+	return b.String()
+}
+
+// splitList splits a list value on unescaped semicolons and unescapes each
+// element. The trailing semicolon is optional.
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	var list []string
+	var elem strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value) && value[i+1] == ';':
+			elem.WriteByte(';')
+			i++
+		case value[i] == '\\' && i+1 < len(value):
+			// Keep other escapes for unescape.
+			elem.WriteByte(value[i])
+			elem.WriteByte(value[i+1])
+			i++
+		case value[i] == ';':
+			list = append(list, unescape(elem.String()))
+			elem.Reset()
+		default:
+			elem.WriteByte(value[i])
+		}
+	}
+
+	if elem.Len() > 0 {
+		list = append(list, unescape(elem.String()))
+	}
+
+	return list
+}
+
+// cutString is strings.Cut, which requires Go 1.18.
+func cutString(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// Locale is a POSIX locale used to look up localized keys.
+type Locale struct {
+	Lang     string
+	Country  string
+	Modifier string
+}
+
+// ParseLocale parses a locale in the lang_COUNTRY.ENCODING@MODIFIER format.
+// Everything but lang is optional, and the encoding is ignored. The C and
+// POSIX locales parse to an empty Locale.
+func ParseLocale(str string) Locale {
+	var locale Locale
+
+	str, locale.Modifier, _ = cutString(str, "@")
+	str, _, _ = cutString(str, ".")
+	locale.Lang, locale.Country, _ = cutString(str, "_")
+
+	if locale.Lang == "C" || locale.Lang == "POSIX" {
+		return Locale{}
+	}
+
+	return locale
+}
+
+// Tag returns the language tag of the locale, or language.Und if the locale
+// is empty or invalid.
+func (l Locale) Tag() language.Tag {
+	if l.Lang == "" {
+		return language.Und
+	}
+
+	tag := l.Lang
+	if l.Country != "" {
+		tag += "-" + l.Country
+	}
+
+	return language.Make(tag)
+}
+
+// CurrentLocale returns the locale for messages from the environment, which is
+// the first set variable of $LC_ALL, $LC_MESSAGES and $LANG.
+func CurrentLocale() Locale {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			return ParseLocale(v)
+		}
+	}
+	return Locale{}
+}
+
+// candidates returns the locale suffixes to try in the order given by the
+// specification.
+func (l Locale) candidates() []string {
+	if l.Lang == "" {
+		return nil
+	}
+
+	candidates := make([]string, 0, 4)
+
+	if l.Country != "" && l.Modifier != "" {
+		candidates = append(candidates, l.Lang+"_"+l.Country+"@"+l.Modifier)
+	}
+	if l.Country != "" {
+		candidates = append(candidates, l.Lang+"_"+l.Country)
+	}
+	if l.Modifier != "" {
+		candidates = append(candidates, l.Lang+"@"+l.Modifier)
+	}
+
+	return append(candidates, l.Lang)
+}
@@ -0,0 +1,234 @@
+package desktopentry
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const firefoxDesktop = `
+# A comment before the first group.
+[Desktop Entry]
+Version=1.0
+Type=Application
+Name=Firefox
+Name[de]=Firefox Browser
+Name[de_AT]=Firefox (Österreich)
+GenericName=Web Browser
+GenericName[de]=Webbrowser
+Comment=Browse\sthe World Wide Web\n
+Keywords=Internet;WWW;Browser;Web;Explorer;
+Keywords[de]=Internet;WWW;Browser\;Suche;
+Exec=/usr/lib/firefox/firefox %u
+Icon=firefox
+Terminal=false
+Categories=Network;WebBrowser;
+MimeType=text/html;text/xml;
+StartupWMClass=firefox
+Actions=new-window;new-private-window;missing;
+
+[Desktop Action new-window]
+Name=New Window
+Name[de]=Neues Fenster
+Exec=/usr/lib/firefox/firefox --new-window %u
+
+[Desktop Action new-private-window]
+Name=New Private Window
+Exec=/usr/lib/firefox/firefox --private-window %u
+`
+
+func TestParse(t *testing.T) {
+	file, err := Parse(strings.NewReader(firefoxDesktop))
+	if err != nil {
+		t.Fatal("cannot parse:", err)
+	}
+
+	if len(file.Groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(file.Groups))
+	}
+
+	action := file.Group("Desktop Action new-private-window")
+	if action == nil {
+		t.Fatal("missing action group")
+	}
+	if name := action.String("Name"); name != "New Private Window" {
+		t.Errorf("unexpected action name %q", name)
+	}
+
+	group := file.Group("Desktop Entry")
+
+	tests := []struct {
+		locale string
+		name   string
+		kws    []string
+	}{
+		{"C", "Firefox", []string{"Internet", "WWW", "Browser", "Web", "Explorer"}},
+		{"en_US.UTF-8", "Firefox", []string{"Internet", "WWW", "Browser", "Web", "Explorer"}},
+		{"de_DE.UTF-8", "Firefox Browser", []string{"Internet", "WWW", "Browser;Suche"}},
+		{"de_AT.UTF-8@euro", "Firefox (Österreich)", []string{"Internet", "WWW", "Browser;Suche"}},
+	}
+
+	for _, test := range tests {
+		locale := ParseLocale(test.locale)
+
+		if name := group.LocaleString("Name", locale); name != test.name {
+			t.Errorf("locale %s: expected name %q, got %q", test.locale, test.name, name)
+		}
+
+		if kws := group.LocaleStrings("Keywords", locale); !reflect.DeepEqual(kws, test.kws) {
+			t.Errorf("locale %s: expected keywords %q, got %q", test.locale, test.kws, kws)
+		}
+	}
+
+	if comment := group.String("Comment"); comment != "Browse the World Wide Web\n" {
+		t.Errorf("unexpected unescaped comment %q", comment)
+	}
+}
+
+func TestLocaleTag(t *testing.T) {
+	tests := map[string]string{
+		"C":                "und",
+		"de_DE.UTF-8":      "de-DE",
+		"sr_RS@latin":      "sr-RS",
+		"tr":               "tr",
+		"invalid_locale!!": "und",
+	}
+
+	for locale, expected := range tests {
+		if tag := ParseLocale(locale).Tag().String(); tag != expected {
+			t.Errorf("locale %s: expected tag %s, got %s", locale, expected, tag)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"key outside group": "Name=Foo\n[Desktop Entry]\n",
+		"duplicate group":   "[Desktop Entry]\n[Desktop Entry]\n",
+		"invalid key":       "[Desktop Entry]\nNa me=Foo\n",
+		"missing equals":    "[Desktop Entry]\nName\n",
+		"unterminated":      "[Desktop Entry\n",
+		"invalid UTF-8":     "[Desktop Entry]\nName=\xff\n",
+	}
+
+	for name, input := range tests {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+	}
+}
+
+func TestNewEntry(t *testing.T) {
+	file, err := Parse(strings.NewReader(firefoxDesktop))
+	if err != nil {
+		t.Fatal("cannot parse:", err)
+	}
+
+	entry, err := NewEntry(file, ParseLocale("de_DE"))
+	if err != nil {
+		t.Fatal("cannot create entry:", err)
+	}
+
+	if entry.Name != "Firefox Browser" || entry.GenericName != "Webbrowser" {
+		t.Errorf("unexpected localized names %q, %q", entry.Name, entry.GenericName)
+	}
+
+	if !reflect.DeepEqual(entry.Categories, []string{"Network", "WebBrowser"}) {
+		t.Errorf("unexpected categories %q", entry.Categories)
+	}
+
+	if untranslated := entry.Raw.String("Name"); untranslated != "Firefox" {
+		t.Errorf("unexpected untranslated name %q", untranslated)
+	}
+
+	if len(entry.Actions) != 2 {
+		t.Fatalf("expected 2 actions, got %d", len(entry.Actions))
+	}
+
+	if action := entry.Actions[0]; action.ID != "new-window" || action.Name != "Neues Fenster" {
+		t.Errorf("unexpected first action %#v", action)
+	}
+
+	args, err := entry.ActionExecArgs(entry.Actions[1])
+	if err != nil || !reflect.DeepEqual(args, []string{"/usr/lib/firefox/firefox", "--private-window"}) {
+		t.Errorf("unexpected private window action args %q (error %v)", args, err)
+	}
+
+	if !entry.ShouldShow(nil) {
+		t.Error("entry should be shown")
+	}
+
+	entry.OnlyShowIn = []string{"KDE"}
+	if entry.ShouldShow([]string{"sway"}) {
+		t.Error("entry only for KDE is shown in sway")
+	}
+
+	entry.OnlyShowIn = nil
+	entry.NotShowIn = []string{"sway"}
+	if entry.ShouldShow([]string{"sway"}) {
+		t.Error("entry not for sway is shown in sway")
+	}
+
+	entry.NotShowIn = nil
+	entry.TryExec = "/nonexistent/gappdash-test"
+	if entry.ShouldShow(nil) {
+		t.Error("entry with missing TryExec is shown")
+	}
+}
+
+func TestMainCategory(t *testing.T) {
+	tests := []struct {
+		categories []string
+		expected   string
+	}{
+		{[]string{"Network", "WebBrowser"}, "Network"},
+		{[]string{"GTK", "Audio", "Player"}, "AudioVideo"},
+		{[]string{"Settings", "System"}, "Settings"},
+		{[]string{"Education", "Science"}, OtherCategory},
+		{nil, OtherCategory},
+	}
+
+	for _, test := range tests {
+		entry := Entry{Categories: test.categories}
+		if category := entry.MainCategory(); category != test.expected {
+			t.Errorf("expected %q for %q, got %q", test.expected, test.categories, category)
+		}
+	}
+}
+
+func TestExecArgs(t *testing.T) {
+	tests := []struct {
+		exec string
+		args []string
+	}{
+		{"firefox %u", []string{"firefox"}},
+		{`"/opt/My App/app" --name "%c" %F`, []string{"/opt/My App/app", "--name", "Foo"}},
+		{`sh -c "echo \"\$HOME\" 100%%"`, []string{"sh", "-c", `echo "$HOME" 100%`}},
+		{"app %i", []string{"app", "--icon", "foo-icon"}},
+	}
+
+	for _, test := range tests {
+		entry := Entry{Name: "Foo", Icon: "foo-icon", Exec: test.exec}
+
+		args, err := entry.ExecArgs()
+		if err != nil {
+			t.Errorf("Exec %q: unexpected error: %v", test.exec, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(args, test.args) {
+			t.Errorf("Exec %q: expected %q, got %q", test.exec, test.args, args)
+		}
+	}
+
+	args := []string{"sh", "-c", "echo \"$HOME\" `date` \\ 100% %c"}
+	entry := Entry{Name: "Foo", Exec: QuoteExec(args)}
+	if quoted, err := entry.ExecArgs(); err != nil || !reflect.DeepEqual(quoted, args) {
+		t.Errorf("expected quoted arguments %q, got %q (%v)", args, quoted, err)
+	}
+
+	entry = Entry{Exec: `app "unterminated`}
+	if _, err := entry.ExecArgs(); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
@@ -0,0 +1,251 @@
+//go:build linux
+
+// Package dirwatch provides a recursive directory watcher using inotify.
+package dirwatch
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/diamondburned/gappdash/internal/fsutil"
+	"github.com/pkg/errors"
+)
+
+// Overflow is sent as the path when the kernel dropped events. The receiver
+// should assume that everything has changed.
+const Overflow = ""
+
+const (
+	// dirMask is the mask for directories inside of the roots.
+	dirMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_CLOSE_WRITE |
+		syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ATTRIB |
+		syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF | syscall.IN_ONLYDIR
+	// parentMask is the mask for the closest existing parent of a root that
+	// does not exist yet.
+	parentMask = syscall.IN_CREATE | syscall.IN_MOVED_TO | syscall.IN_ONLYDIR
+)
+
+// Watcher watches directories recursively for changes. Directories that don't
+// exist yet are watched for as well, so they're watched once they're created.
+type Watcher struct {
+	fd     int
+	file   *os.File
+	events chan string
+
+	roots   []string
+	pending []string
+	paths   map[int32]string // wd -> directory
+}
+
+// New creates a new watcher that watches the given roots and everything in
+// them.
+func New(roots []string) (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to init inotify")
+	}
+
+	w := &Watcher{
+		// The file is non-blocking, so reads go through the runtime poller,
+		// and closing it stops the reading goroutine.
+		fd:     fd,
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		events: make(chan string, 64),
+		roots:  make([]string, len(roots)),
+		paths:  make(map[int32]string),
+	}
+
+	for i, root := range roots {
+		w.roots[i] = filepath.Clean(root)
+	}
+
+	// Treat all roots as pending initially. Don't send events for existing
+	// roots, since nothing changed.
+	w.pending = append(w.pending, w.roots...)
+	w.watchPending(false)
+
+	go w.read()
+
+	return w, nil
+}
+
+// Events returns the channel that receives the paths of changed files and
+// directories. The channel is closed once the watcher is closed.
+func (w *Watcher) Events() <-chan string {
+	return w.events
+}
+
+// Close stops the watcher.
+func (w *Watcher) Close() error {
+	return w.file.Close()
+}
+
+func (w *Watcher) read() {
+	defer close(w.events)
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			offset += syscall.SizeofInotifyEvent
+
+			nameBytes := buf[offset : offset+int(event.Len)]
+			offset += int(event.Len)
+
+			// The name is padded with NUL bytes.
+			name := string(bytes.TrimRight(nameBytes, "\x00"))
+			w.handle(event.Wd, event.Mask, name)
+		}
+	}
+}
+
+func (w *Watcher) handle(wd int32, mask uint32, name string) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		w.events <- Overflow
+		return
+	}
+
+	dir, ok := w.paths[wd]
+	if !ok {
+		return
+	}
+
+	if mask&syscall.IN_IGNORED != 0 {
+		// The directory is gone. If it is a root or a parent of one, then
+		// watch for it to be created again.
+		delete(w.paths, wd)
+		if w.isRootOrParent(dir) {
+			w.pending = w.unwatchedRoots()
+			w.watchPending(true)
+		}
+		return
+	}
+
+	path := dir
+	if name != "" {
+		path = filepath.Join(dir, name)
+	}
+
+	isNewDir := false
+	if mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		// Links to directories don't have IN_ISDIR, but they're followed.
+		isNewDir = mask&syscall.IN_ISDIR != 0 || isDir(path)
+	}
+
+	if !w.inRoot(dir) {
+		// This is the parent of a pending root. Check if a directory on the
+		// way to the root was created.
+		if isNewDir {
+			w.watchPending(true)
+		}
+		return
+	}
+
+	if isNewDir {
+		w.watchTree(path)
+	}
+
+	w.events <- path
+}
+
+// watchPending watches the pending roots that exist and watches the parents of
+// the rest. If send is true, then the paths of the newly watched roots are
+// sent.
+func (w *Watcher) watchPending(send bool) {
+	pending := w.pending[:0:0]
+
+	for _, root := range w.pending {
+		if _, err := os.Stat(root); err == nil {
+			w.watchTree(root)
+			if send {
+				w.events <- root
+			}
+			continue
+		}
+
+		parent := filepath.Dir(root)
+		for parent != filepath.Dir(parent) {
+			if _, err := os.Stat(parent); err == nil {
+				break
+			}
+			parent = filepath.Dir(parent)
+		}
+
+		w.addWatch(parent, parentMask)
+		pending = append(pending, root)
+	}
+
+	w.pending = pending
+}
+
+// watchTree watches the given directory and all directories inside of it.
+// Links to directories are followed, and inotify watches what they point to.
+func (w *Watcher) watchTree(root string) {
+	fsutil.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			w.addWatch(path, dirMask)
+		}
+		return nil
+	})
+}
+
+func (w *Watcher) addWatch(path string, mask uint32) {
+	wd, err := syscall.InotifyAddWatch(w.fd, path, mask)
+	if err == nil {
+		w.paths[int32(wd)] = path
+	}
+}
+
+func (w *Watcher) unwatchedRoots() []string {
+	watched := make(map[string]bool, len(w.paths))
+	for _, path := range w.paths {
+		watched[path] = true
+	}
+
+	var unwatched []string
+	for _, root := range w.roots {
+		if !watched[root] {
+			unwatched = append(unwatched, root)
+		}
+	}
+
+	return unwatched
+}
+
+func (w *Watcher) inRoot(path string) bool {
+	for _, root := range w.roots {
+		if isParentOrSelf(root, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) isRootOrParent(path string) bool {
+	for _, root := range w.roots {
+		if isParentOrSelf(path, root) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDir(path string) bool {
+	s, err := os.Stat(path)
+	return err == nil && s.IsDir()
+}
+
+func isParentOrSelf(parent, path string) bool {
+	return path == parent || strings.HasPrefix(path, parent+string(filepath.Separator))
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package dirwatch
+
+import "github.com/pkg/errors"
+
+// Overflow is sent as the path when the kernel dropped events.
+const Overflow = ""
+
+// Watcher is not supported on this platform.
+type Watcher struct{}
+
+// New always returns an error, since inotify is only available on Linux.
+func New(roots []string) (*Watcher, error) {
+	return nil, errors.New("directory watching is only supported on Linux")
+}
+
+// Events returns a nil channel.
+func (w *Watcher) Events() <-chan string { return nil }
+
+// Close does nothing.
+func (w *Watcher) Close() error { return nil }
@@ -0,0 +1,104 @@
+//go:build linux
+
+package dirwatch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func expectEvent(t *testing.T, w *Watcher, path string) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case event := <-w.Events():
+			if event == path {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for event for %s", path)
+		}
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	tmp := t.TempDir()
+
+	existing := filepath.Join(tmp, "existing", "applications")
+	if err := os.MkdirAll(existing, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The parent of this root doesn't exist either.
+	late := filepath.Join(tmp, "late", "share", "applications")
+
+	w, err := New([]string{existing, late})
+	if err != nil {
+		t.Fatal("cannot create watcher:", err)
+	}
+	defer w.Close()
+
+	file := filepath.Join(existing, "foo.desktop")
+	if err := os.WriteFile(file, []byte("[Desktop Entry]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, w, file)
+
+	// Files in new subdirectories must be watched as well.
+	sub := filepath.Join(existing, "kde4")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, w, sub)
+
+	subFile := filepath.Join(sub, "bar.desktop")
+	if err := os.WriteFile(subFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, w, subFile)
+
+	// So must files in linked directories.
+	target := filepath.Join(tmp, "store", "qux")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	link := filepath.Join(existing, "nix")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, w, link)
+
+	linkFile := filepath.Join(link, "qux.desktop")
+	if err := os.WriteFile(filepath.Join(target, "qux.desktop"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, w, linkFile)
+
+	if err := os.MkdirAll(late, 0755); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, w, late)
+
+	lateFile := filepath.Join(late, "baz.desktop")
+	if err := os.WriteFile(lateFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, w, lateFile)
+
+	if err := os.Remove(lateFile); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, w, lateFile)
+
+	w.Close()
+
+	// The channel must be closed after closing.
+	for range w.Events() {
+	}
+}
@@ -0,0 +1,91 @@
+package emoji
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	_ "embed"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed annotations.xml
+var annotationsXML string
+
+// Keywords maps emoji without variation selectors to their CLDR keywords.
+type Keywords map[string][]string
+
+// Get returns the keywords of the given emoji.
+func (k Keywords) Get(char string) []string {
+	return k[strings.ReplaceAll(char, "\uFE0F", "")]
+}
+
+// ParseAnnotations parses the keywords from CLDR annotations, which are an
+// LDML file such as annotations/en.xml of CLDR.
+func ParseAnnotations(r io.Reader) (Keywords, error) {
+	var ldml struct {
+		Annotations []struct {
+			CP   string `xml:"cp,attr"`
+			Type string `xml:"type,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"annotations>annotation"`
+	}
+
+	if err := xml.NewDecoder(r).Decode(&ldml); err != nil {
+		return nil, errors.Wrap(err, "failed to decode annotations")
+	}
+
+	keywords := make(Keywords, len(ldml.Annotations))
+
+	for _, annotation := range ldml.Annotations {
+		// The other annotation is the short name for text-to-speech.
+		if annotation.Type != "" {
+			continue
+		}
+
+		for _, keyword := range strings.Split(annotation.Text, "|") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				keywords[annotation.CP] = append(keywords[annotation.CP], keyword)
+			}
+		}
+	}
+
+	return keywords, nil
+}
+
+// LoadAnnotations parses the CLDR annotations at the given path. Annotations
+// that do not exist have no keywords.
+func LoadAnnotations(path string) (Keywords, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to open annotations")
+	}
+	defer f.Close()
+
+	return ParseAnnotations(f)
+}
+
+var defaultKeywords struct {
+	once     sync.Once
+	keywords Keywords
+}
+
+// DefaultKeywords returns the embedded English keywords. The returned map must
+// not be modified.
+func DefaultKeywords() Keywords {
+	defaultKeywords.once.Do(func() {
+		keywords, err := ParseAnnotations(strings.NewReader(annotationsXML))
+		if err != nil {
+			panic("BUG: invalid embedded annotations: " + err.Error())
+		}
+		defaultKeywords.keywords = keywords
+	})
+
+	return defaultKeywords.keywords
+}
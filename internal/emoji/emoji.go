@@ -0,0 +1,226 @@
+// Package emoji provides a picker of emoji and other Unicode characters. The
+// emoji and their CLDR short names come from the embedded emoji-test.txt of
+// Unicode, while the names of other characters come from the Unicode
+// Character Database. The keywords of the emoji, such as "happy", come from the
+// embedded annotations.xml, which only has the English keywords of commonly
+// searched emoji, since the full CLDR annotations are several times larger than
+// the emoji. The CLDR annotations installed on the system can be used instead.
+package emoji
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	_ "embed"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/runenames"
+)
+
+//go:embed emoji-test.txt
+var emojiTest string
+
+// Emoji is an emoji or a character.
+type Emoji struct {
+	Char string
+	// Name is the CLDR short name of an emoji, such as "grinning face", or the
+	// lowercase Unicode name of a character.
+	Name     string
+	Group    string
+	Subgroup string
+	// Variants contains the variants of the emoji, such as its skin tones.
+	Variants []Emoji
+}
+
+// Parse parses emoji in the format of emoji-test.txt. Only fully-qualified
+// emoji are returned. Emoji that only differ from an earlier emoji by their skin
+// tones or hair styles, such as "waving hand: light skin tone", are made
+// variants of that emoji. Other sequences, such as "family: man, woman, boy",
+// are separate emoji.
+func Parse(r io.Reader) ([]Emoji, error) {
+	var emojis []Emoji
+	var group, subgroup string
+
+	// bases maps the names of the emoji to their indices.
+	bases := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "#") {
+			if strings.HasPrefix(line, "# group:") {
+				group = strings.TrimSpace(strings.TrimPrefix(line, "# group:"))
+			} else if strings.HasPrefix(line, "# subgroup:") {
+				subgroup = strings.TrimSpace(strings.TrimPrefix(line, "# subgroup:"))
+			}
+			continue
+		}
+
+		emoji, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+
+		emoji.Group = group
+		emoji.Subgroup = subgroup
+
+		if base, ok := findBase(bases, emoji.Name); ok {
+			emojis[base].Variants = append(emojis[base].Variants, emoji)
+			continue
+		}
+
+		bases[emoji.Name] = len(emojis)
+		emojis = append(emojis, emoji)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read emoji")
+	}
+
+	return emojis, nil
+}
+
+// modifiers are the qualifiers in the names of emoji that only change the skin
+// tone or the hair style of another emoji.
+var modifiers = map[string]bool{
+	"light skin tone":        true,
+	"medium-light skin tone": true,
+	"medium skin tone":       true,
+	"medium-dark skin tone":  true,
+	"dark skin tone":         true,
+	"red hair":               true,
+	"curly hair":             true,
+	"white hair":             true,
+	"bald":                   true,
+}
+
+// findBase returns the index in bases of the emoji that the emoji with the
+// given name is a variant of. The modifiers are removed from the qualifiers
+// after the colon, so "kiss: woman, man, light skin tone" is a variant of
+// "kiss: woman, man". Since "kiss" is "kiss: person, person", the name without
+// the qualifiers is tried as well.
+func findBase(bases map[string]int, name string) (int, bool) {
+	i := strings.Index(name, ": ")
+	if i == -1 {
+		return 0, false
+	}
+
+	qualifiers := strings.Split(name[i+2:], ", ")
+
+	var kept []string
+	for _, qualifier := range qualifiers {
+		if !modifiers[qualifier] {
+			kept = append(kept, qualifier)
+		}
+	}
+
+	if len(kept) == len(qualifiers) {
+		return 0, false
+	}
+
+	if len(kept) > 0 {
+		if base, ok := bases[name[:i]+": "+strings.Join(kept, ", ")]; ok {
+			return base, true
+		}
+	}
+
+	base, ok := bases[name[:i]]
+	return base, ok
+}
+
+// parseLine parses a line such as
+//
+//	1F600 ; fully-qualified # 😀 E1.0 grinning face
+//
+// It returns false if the line isn't a fully-qualified emoji.
+func parseLine(line string) (Emoji, bool) {
+	fields := strings.SplitN(line, ";", 2)
+	if len(fields) != 2 {
+		return Emoji{}, false
+	}
+
+	comment := strings.SplitN(fields[1], "#", 2)
+	if len(comment) != 2 || strings.TrimSpace(comment[0]) != "fully-qualified" {
+		return Emoji{}, false
+	}
+
+	var char strings.Builder
+	for _, codePoint := range strings.Fields(fields[0]) {
+		r, err := strconv.ParseUint(codePoint, 16, 32)
+		if err != nil {
+			return Emoji{}, false
+		}
+		char.WriteRune(rune(r))
+	}
+
+	// The comment is the emoji, the version in which it was added and its
+	// name.
+	words := strings.Fields(comment[1])
+	if len(words) < 3 || !strings.HasPrefix(words[1], "E") {
+		return Emoji{}, false
+	}
+
+	return Emoji{
+		Char: char.String(),
+		Name: strings.Join(words[2:], " "),
+	}, true
+}
+
+var all struct {
+	once   sync.Once
+	emojis []Emoji
+}
+
+// All returns the embedded emoji in the CLDR order. The returned slice must not
+// be modified.
+func All() []Emoji {
+	all.once.Do(func() {
+		emojis, err := Parse(strings.NewReader(emojiTest))
+		if err != nil {
+			panic("BUG: invalid embedded emoji: " + err.Error())
+		}
+		all.emojis = emojis
+	})
+
+	return all.emojis
+}
+
+// Characters returns the named graphic Unicode characters that aren't single
+// character emoji in All, in the order of their code points. Characters whose
+// names are only their code points, such as CJK ideographs, are skipped.
+func Characters() []Emoji {
+	emojis := make(map[rune]bool)
+	for _, emoji := range All() {
+		for _, variant := range append([]Emoji{emoji}, emoji.Variants...) {
+			if r := []rune(variant.Char); len(r) == 1 || len(r) == 2 && r[1] == '\uFE0F' {
+				emojis[r[0]] = true
+			}
+		}
+	}
+
+	var chars []Emoji
+
+	for r := rune(0); r <= unicode.MaxRune; r++ {
+		if !unicode.IsGraphic(r) || unicode.IsSpace(r) || emojis[r] {
+			continue
+		}
+
+		name := runenames.Name(r)
+		if name == "" || strings.HasPrefix(name, "<") || strings.HasSuffix(name, fmt.Sprintf("-%04X", r)) {
+			continue
+		}
+
+		chars = append(chars, Emoji{
+			Char: string(r),
+			Name: strings.ToLower(name),
+		})
+	}
+
+	return chars
+}
@@ -0,0 +1,196 @@
+package emoji
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+)
+
+const testEmoji = `# group: People & Body
+# subgroup: hand-fingers-open
+1F44B; fully-qualified # 👋 E0.6 waving hand
+1F44B 1F3FB; fully-qualified # 👋🏻 E1.0 waving hand: light skin tone
+270B; fully-qualified # ✋ E0.6 raised hand
+# subgroup: person
+1F468; fully-qualified # 👨 E0.6 man
+1F468 200D 1F9B0; fully-qualified # 👨‍🦰 E11.0 man: red hair
+1F468 1F3FB 200D 1F9B0; fully-qualified # 👨🏻‍🦰 E11.0 man: light skin tone, red hair
+1F9D4 200D 2642 FE0F; fully-qualified # 🧔‍♂️ E13.1 man: beard
+# subgroup: family
+1F48F; fully-qualified # 💏 E0.6 kiss
+1F48F 1F3FB; fully-qualified # 💏🏻 E13.1 kiss: light skin tone
+1F469 200D 2764 FE0F 200D 1F48B 200D 1F468; fully-qualified # 👩‍❤️‍💋‍👨 E2.0 kiss: woman, man
+1F469 1F3FB 200D 2764 FE0F 200D 1F48B 200D 1F468 1F3FB; fully-qualified # 👩🏻‍❤️‍💋‍👨🏻 E13.1 kiss: woman, man, light skin tone
+1F9D1 1F3FB 200D 2764 FE0F 200D 1F48B 200D 1F9D1 1F3FC; fully-qualified # 🧑🏻‍❤️‍💋‍🧑🏼 E13.1 kiss: person, person, light skin tone, medium-light skin tone
+1F468 200D 1F469 200D 1F466; fully-qualified # 👨‍👩‍👦 E2.0 family: man, woman, boy
+# group: Flags
+# subgroup: country-flag
+1F1E9 1F1EA; fully-qualified # 🇩🇪 E2.0 flag: Germany
+263A; unqualified # ☺ E0.6 smiling face
+`
+
+func TestParse(t *testing.T) {
+	emojis, err := Parse(strings.NewReader(testEmoji))
+	if err != nil {
+		t.Fatal("cannot parse:", err)
+	}
+
+	var names []string
+	for _, emoji := range emojis {
+		names = append(names, emoji.Name)
+	}
+
+	// Only skin tones and hair styles are variants.
+	expected := []string{"waving hand", "raised hand", "man", "man: beard", "kiss", "kiss: woman, man", "family: man, woman, boy", "flag: Germany"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected emoji %q, got %q", expected, names)
+	}
+
+	wave := emojis[0]
+	if wave.Char != "👋" || wave.Name != "waving hand" || wave.Group != "People & Body" || wave.Subgroup != "hand-fingers-open" {
+		t.Errorf("unexpected emoji %+v", wave)
+	}
+	if len(wave.Variants) != 1 || wave.Variants[0].Char != "👋🏻" {
+		t.Errorf("unexpected variants %+v", wave.Variants)
+	}
+
+	if man := emojis[2]; len(man.Variants) != 2 || man.Variants[1].Name != "man: light skin tone, red hair" {
+		t.Errorf("unexpected variants of man %+v", man.Variants)
+	}
+
+	// "kiss" is the same as "kiss: person, person".
+	if kiss := emojis[4]; len(kiss.Variants) != 2 || kiss.Variants[1].Char != "🧑🏻‍❤️‍💋‍🧑🏼" {
+		t.Errorf("unexpected variants of kiss %+v", kiss.Variants)
+	}
+	if kiss := emojis[5]; len(kiss.Variants) != 1 || kiss.Variants[0].Char != "👩🏻‍❤️‍💋‍👨🏻" {
+		t.Errorf("unexpected variants of kiss: woman, man %+v", kiss.Variants)
+	}
+
+	if flag := emojis[7]; flag.Char != "🇩🇪" || flag.Name != "flag: Germany" || flag.Group != "Flags" {
+		t.Errorf("unexpected flag %+v", flag)
+	}
+}
+
+func TestAll(t *testing.T) {
+	emojis := All()
+	if len(emojis) < 1000 {
+		t.Fatalf("expected the embedded emoji, got only %d", len(emojis))
+	}
+
+	if emojis[0].Char != "😀" || emojis[0].Name != "grinning face" {
+		t.Errorf("unexpected first emoji %+v", emojis[0])
+	}
+}
+
+const testAnnotations = `<?xml version="1.0" encoding="UTF-8" ?>
+<ldml>
+	<annotations>
+		<annotation cp="😂">face | joy | laugh | lol | tear</annotation>
+		<annotation cp="😂" type="tts">face with tears of joy</annotation>
+		<annotation cp="☺">face | outlined | relaxed | smile</annotation>
+	</annotations>
+</ldml>
+`
+
+func TestParseAnnotations(t *testing.T) {
+	keywords, err := ParseAnnotations(strings.NewReader(testAnnotations))
+	if err != nil {
+		t.Fatal("cannot parse:", err)
+	}
+
+	if expected := []string{"face", "joy", "laugh", "lol", "tear"}; !reflect.DeepEqual(keywords.Get("😂"), expected) {
+		t.Errorf("expected keywords %q, got %q", expected, keywords.Get("😂"))
+	}
+
+	// The variation selector of the emoji isn't in the annotations.
+	if smiling := keywords.Get("☺\uFE0F"); len(smiling) != 4 {
+		t.Errorf("unexpected keywords of smiling face %q", smiling)
+	}
+}
+
+func TestDefaultKeywords(t *testing.T) {
+	keywords := DefaultKeywords()
+
+	for _, char := range []string{"😂", "❤\uFE0F", "👍"} {
+		if len(keywords.Get(char)) == 0 {
+			t.Errorf("expected embedded keywords of %s", char)
+		}
+	}
+
+	for char := range keywords {
+		if strings.Contains(char, "\uFE0F") {
+			t.Errorf("unexpected variation selector in %q", char)
+		}
+	}
+}
+
+// hasGlyph returns true if the items have the given glyph.
+func hasGlyph(items []appindex.Item, glyph string) bool {
+	for _, item := range items {
+		if item.Glyph == glyph {
+			return true
+		}
+	}
+	return false
+}
+
+func TestProvider(t *testing.T) {
+	var copied string
+	p := NewProvider(":", true, "", func(char string) { copied = char })
+
+	if !hasGlyph(p.Search("lol"), "😂") {
+		t.Error("expected to find emoji by their embedded keywords")
+	}
+
+	items := p.Search("waving")
+	if len(items) == 0 || items[0].Glyph != "👋" || items[0].Subtitle != "U+1F44B" {
+		t.Fatalf("unexpected items for waving: %+v", items)
+	}
+
+	if variants := items[0].Actions(); len(variants) != 5 {
+		t.Errorf("expected 5 skin tones, got %d", len(variants))
+	}
+
+	items[0].Activate()
+	if copied != "👋" {
+		t.Errorf("unexpected copied character %q", copied)
+	}
+
+	items = p.Search("greek small letter alpha")
+	if len(items) == 0 || items[0].Glyph != "α" {
+		t.Errorf("unexpected items for alpha: %+v", items)
+	}
+
+	// Emoji rank above characters that match as well.
+	items = p.Search("smiling face")
+	if len(items) == 0 || items[0].Class != "emoji" || items[0].Title != "smiling face" {
+		t.Errorf("unexpected first item for smiling face: %+v", items[0])
+	}
+}
+
+func TestProviderAnnotations(t *testing.T) {
+	annotations := filepath.Join(t.TempDir(), "en.xml")
+	if err := os.WriteFile(annotations, []byte(testAnnotations), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProvider(":", false, annotations, func(string) {})
+
+	// The keywords of the system replace the embedded ones.
+	if !hasGlyph(p.Search("lol"), "😂") {
+		t.Error("expected to find emoji by the keywords of the system")
+	}
+	if hasGlyph(p.Search("lol"), "🤣") {
+		t.Error("expected the embedded keywords to be replaced")
+	}
+
+	// Missing annotations fall back to the embedded keywords.
+	p = NewProvider(":", false, filepath.Join(t.TempDir(), "missing.xml"), func(string) {})
+	if !hasGlyph(p.Search("lol"), "🤣") {
+		t.Error("expected the embedded keywords without the annotations of the system")
+	}
+}
@@ -0,0 +1,164 @@
+package emoji
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+)
+
+const maxItems = 200
+
+// characterScore scales the scores of characters, so that emoji come first.
+const characterScore = 0.8
+
+// weights are the weights of the records of emoji. The keywords come from the
+// annotations, while the categories are the group and the subgroup words,
+// such as "Smileys & Emotion face smiling".
+var weights = appindex.Weights{
+	appindex.FieldName:       1,
+	appindex.FieldKeywords:   0.5,
+	appindex.FieldCategories: 0.3,
+}
+
+// Provider is the appindex.PrefixProvider of the emoji picker. Activating an
+// item copies its character. The emoji are loaded on the first search.
+type Provider struct {
+	prefix      string
+	characters  bool
+	annotations string
+	copy        func(char string)
+
+	once     sync.Once
+	mutex    sync.Mutex
+	searcher appindex.Searcher
+	emojis   []Emoji
+}
+
+// NewProvider creates a new emoji picker that is used for queries that start
+// with the given prefix. If characters is true, then all other Unicode
+// characters are searched as well. The keywords of the emoji are read from the
+// CLDR annotations at the given path if it's not empty and they exist, or else
+// the embedded keywords are used. copy copies a character to the clipboard.
+func NewProvider(prefix string, characters bool, annotations string, copy func(char string)) *Provider {
+	return &Provider{
+		prefix:      prefix,
+		characters:  characters,
+		annotations: annotations,
+		copy:        copy,
+	}
+}
+
+// Prefix implements appindex.PrefixProvider.
+func (p *Provider) Prefix() string {
+	return p.prefix
+}
+
+func (p *Provider) load() {
+	p.emojis = All()
+	if p.characters {
+		p.emojis = append(p.emojis[:len(p.emojis):len(p.emojis)], Characters()...)
+	}
+
+	var keywords Keywords
+	if p.annotations != "" {
+		var err error
+		if keywords, err = LoadAnnotations(p.annotations); err != nil {
+			log.Println("cannot load emoji keywords:", err)
+		}
+	}
+	if keywords == nil {
+		keywords = DefaultKeywords()
+	}
+
+	records := make([]appindex.Record, len(p.emojis))
+	for i, emoji := range p.emojis {
+		records[i][appindex.FieldName] = emoji.Name
+		records[i][appindex.FieldKeywords] = strings.Join(keywords.Get(emoji.Char), " ")
+		records[i][appindex.FieldCategories] = emoji.Group + " " + strings.ReplaceAll(emoji.Subgroup, "-", " ")
+	}
+
+	p.searcher = appindex.NewSubstringSearcher(false, weights)
+	p.searcher.Index(records)
+}
+
+// Search implements appindex.Provider. The query must not have the prefix. All
+// emoji are returned in their order if the query is empty.
+func (p *Provider) Search(query string) []appindex.Item {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.once.Do(p.load)
+
+	if strings.TrimSpace(query) == "" {
+		items := make([]appindex.Item, 0, maxItems)
+		for _, emoji := range All() {
+			if len(items) == maxItems {
+				break
+			}
+			items = append(items, p.item(emoji))
+		}
+		return items
+	}
+
+	matches := p.searcher.Search(query)
+
+	items := make([]appindex.Item, 0, len(matches))
+	for _, match := range matches {
+		emoji := p.emojis[match.Index]
+
+		item := p.item(emoji)
+		item.Score = match.Score
+		item.TitleRanges = match.Ranges[appindex.FieldName]
+
+		if emoji.Group == "" {
+			item.Score *= characterScore
+		}
+
+		items = append(items, item)
+	}
+
+	appindex.SortItems(items)
+
+	if len(items) > maxItems {
+		items = items[:maxItems]
+	}
+
+	return items
+}
+
+func (p *Provider) item(emoji Emoji) appindex.Item {
+	char := emoji.Char
+
+	item := appindex.Item{
+		ID:       char,
+		Title:    emoji.Name,
+		Subtitle: codePoints(char),
+		Glyph:    char,
+		Class:    "emoji",
+		Activate: func() { p.copy(char) },
+	}
+
+	if len(emoji.Variants) > 0 {
+		item.Actions = func() []appindex.Item {
+			variants := make([]appindex.Item, len(emoji.Variants))
+			for i, variant := range emoji.Variants {
+				variants[i] = p.item(variant)
+			}
+			return variants
+		}
+	}
+
+	return item
+}
+
+// codePoints returns the code points of the string in the U+XXXX notation.
+func codePoints(str string) string {
+	var points []string
+	for _, r := range str {
+		points = append(points, fmt.Sprintf("U+%04X", r))
+	}
+	return strings.Join(points, " ")
+}
@@ -0,0 +1,119 @@
+// Package execpath lists the executables in the directories of $PATH.
+package execpath
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Cache lists the executables in $PATH. Each directory is only read again once
+// its modification time changes, which happens whenever a file is added to or
+// removed from it. All its methods are thread-safe.
+type Cache struct {
+	mutex sync.Mutex
+	dirs  map[string]dirCache
+
+	// names is the last merged list and the path that it was made from.
+	names []string
+	path  string
+}
+
+type dirCache struct {
+	modTime time.Time
+	names   []string
+}
+
+// Executables returns the sorted names of all executables in $PATH. The
+// returned slice must not be modified.
+func (c *Cache) Executables() []string {
+	return c.ExecutablesIn(os.Getenv("PATH"))
+}
+
+// ExecutablesIn is like Executables, but for the given list of directories in
+// the format of $PATH.
+func (c *Cache) ExecutablesIn(path string) []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.dirs == nil {
+		c.dirs = make(map[string]dirCache)
+	}
+
+	dirs := filepath.SplitList(path)
+	changed := path != c.path
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		var modTime time.Time
+		if s, err := os.Stat(dir); err == nil {
+			modTime = s.ModTime()
+		}
+
+		if cache, ok := c.dirs[dir]; ok && cache.modTime.Equal(modTime) {
+			continue
+		}
+
+		c.dirs[dir] = dirCache{
+			modTime: modTime,
+			names:   readExecutables(dir),
+		}
+		changed = true
+	}
+
+	if !changed {
+		return c.names
+	}
+
+	seen := make(map[string]bool)
+	names := make([]string, 0, len(c.names))
+
+	for _, dir := range dirs {
+		for _, name := range c.dirs[dir].names {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	c.names = names
+	c.path = path
+
+	return names
+}
+
+// readExecutables returns the names of the executable files in the given
+// directory, or nil if it cannot be read.
+func readExecutables(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		// Follow symlinks, which are common in directories such as
+		// /usr/local/bin.
+		s, err := os.Stat(filepath.Join(dir, entry.Name()))
+		if err != nil || !s.Mode().IsRegular() || s.Mode().Perm()&0111 == 0 {
+			continue
+		}
+
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
@@ -0,0 +1,66 @@
+package execpath
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	dir1 := t.TempDir()
+	dir2 := t.TempDir()
+
+	writeFile(t, filepath.Join(dir1, "foo"), 0755)
+	writeFile(t, filepath.Join(dir1, "not-executable"), 0644)
+	writeFile(t, filepath.Join(dir2, "bar"), 0755)
+	writeFile(t, filepath.Join(dir2, "foo"), 0755)
+
+	if err := os.Mkdir(filepath.Join(dir2, "directory"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(filepath.Join(dir1, "foo"), filepath.Join(dir2, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	path := dir1 + string(filepath.ListSeparator) + dir2 + string(filepath.ListSeparator) + "/nonexistent"
+
+	var c Cache
+
+	names := c.ExecutablesIn(path)
+	if expected := []string{"bar", "foo", "link"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %q, got %q", expected, names)
+	}
+
+	// Change a file without touching the directory. The cached list must be
+	// returned.
+	if err := os.Chmod(filepath.Join(dir1, "not-executable"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if names := c.ExecutablesIn(path); len(names) != 3 {
+		t.Errorf("expected the cached names, got %q", names)
+	}
+
+	writeFile(t, filepath.Join(dir2, "baz"), 0755)
+	// Make sure that the modification time differs on coarse filesystems.
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(dir2, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	names = c.ExecutablesIn(path)
+	if expected := []string{"bar", "baz", "foo", "link"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %q after adding baz, got %q", expected, names)
+	}
+}
+
+func writeFile(t *testing.T, path string, perm os.FileMode) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), perm); err != nil {
+		t.Fatal(err)
+	}
+}
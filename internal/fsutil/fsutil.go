@@ -0,0 +1,57 @@
+// Package fsutil provides file system helpers that the standard library lacks.
+package fsutil
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WalkDir is like filepath.WalkDir, except that it follows symbolic links to
+// directories, including root itself. The paths given to fn are inside root as
+// if the links were directories. Every directory is only walked once, so links
+// that loop are skipped.
+//
+// Applications directories are often links, such as in Nix profiles, which is
+// why this exists.
+func WalkDir(root string, fn fs.WalkDirFunc) error {
+	return walkDir(root, fn, make(map[string]bool))
+}
+
+func walkDir(root string, fn fs.WalkDirFunc, visited map[string]bool) error {
+	real, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+
+	if visited[real] {
+		return nil
+	}
+
+	return filepath.WalkDir(real, func(resolved string, d fs.DirEntry, err error) error {
+		// Give the path inside of root rather than the resolved one.
+		path := root + strings.TrimPrefix(resolved, real)
+
+		if err != nil {
+			return fn(path, d, err)
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			if s, err := os.Stat(path); err == nil && s.IsDir() {
+				return walkDir(path, fn, visited)
+			}
+		}
+
+		if d.IsDir() {
+			// The directories that filepath.WalkDir visits are never links
+			// themselves, so their paths inside of real are resolved already.
+			if visited[resolved] {
+				return fs.SkipDir
+			}
+			visited[resolved] = true
+		}
+
+		return fn(path, d, err)
+	})
+}
@@ -0,0 +1,53 @@
+// Package gioapp launches applications through GIO, which desktopentry cannot
+// do without depending on GLib.
+package gioapp
+
+// #cgo pkg-config: gio-2.0 gio-unix-2.0
+// #include <stdlib.h>
+// #include <gio/gio.h>
+// #include <gio/gdesktopappinfo.h>
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/pkg/errors"
+)
+
+// Launch launches the application with the given desktop file ID through GIO,
+// which handles Terminal, D-Bus activation and startup notification. If action
+// is not empty, then that action of the application is launched instead. known
+// is false if GIO does not know the ID. It is a desktopentry.Launcher.
+//
+// The gotk4 version in use has no bindings for GDesktopAppInfo, so it is used
+// directly.
+func Launch(id, action string) (known bool, err error) {
+	if id == "" {
+		return false, nil
+	}
+
+	cid := C.CString(id)
+	defer C.free(unsafe.Pointer(cid))
+
+	info := C.g_desktop_app_info_new((*C.gchar)(unsafe.Pointer(cid)))
+	if info == nil {
+		return false, nil
+	}
+	defer C.g_object_unref(C.gpointer(unsafe.Pointer(info)))
+
+	if action != "" {
+		caction := C.CString(action)
+		defer C.free(unsafe.Pointer(caction))
+
+		C.g_desktop_app_info_launch_action(info, (*C.gchar)(unsafe.Pointer(caction)), nil)
+		return true, nil
+	}
+
+	var gerr *C.GError
+	if C.g_app_info_launch((*C.GAppInfo)(unsafe.Pointer(info)), nil, nil, &gerr) == 0 {
+		defer C.g_error_free(gerr)
+		return true, errors.New(C.GoString((*C.char)(unsafe.Pointer(gerr.message))))
+	}
+
+	return true, nil
+}
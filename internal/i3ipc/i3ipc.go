@@ -0,0 +1,257 @@
+// Package i3ipc implements a minimal client of the IPC protocol of sway and i3
+// as well as a window switcher based on it.
+package i3ipc
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const magic = "i3-ipc"
+
+// MessageType is the type of an IPC message.
+type MessageType uint32
+
+const (
+	RunCommand MessageType = 0
+	GetTree    MessageType = 4
+)
+
+// replyTimeout is how long Send waits for the compositor, which should reply
+// right away, so that a hung compositor cannot hang the caller.
+const replyTimeout = 500 * time.Millisecond
+
+// SocketPath returns the path of the IPC socket of the running sway or i3,
+// which is $SWAYSOCK or $I3SOCK.
+func SocketPath() (string, error) {
+	for _, env := range []string{"SWAYSOCK", "I3SOCK"} {
+		if path := os.Getenv(env); path != "" {
+			return path, nil
+		}
+	}
+	return "", errors.New("neither $SWAYSOCK nor $I3SOCK is set")
+}
+
+// Conn is a connection to the IPC socket. All its methods are thread-safe.
+type Conn struct {
+	mutex sync.Mutex
+	conn  net.Conn
+}
+
+// Dial connects to the IPC socket at the given path.
+func Dial(path string) (*Conn, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to IPC socket")
+	}
+	return &Conn{conn: conn}, nil
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Send sends a message of the given type with the given payload and returns
+// the payload of the reply. It fails if the reply takes longer than half a
+// second.
+func (c *Conn) Send(typ MessageType, payload []byte) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := c.conn.SetDeadline(time.Now().Add(replyTimeout)); err != nil {
+		return nil, errors.Wrap(err, "failed to set deadline")
+	}
+
+	if err := WriteMessage(c.conn, typ, payload); err != nil {
+		return nil, errors.Wrap(err, "failed to send message")
+	}
+
+	replyType, reply, err := ReadMessage(c.conn)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read reply")
+	}
+
+	if replyType != typ {
+		return nil, fmt.Errorf("unexpected reply type %d to message type %d", replyType, typ)
+	}
+
+	return reply, nil
+}
+
+// WriteMessage writes a message in the IPC framing, which is the magic string
+// followed by the length of the payload, the type and the payload.
+func WriteMessage(w io.Writer, typ MessageType, payload []byte) error {
+	msg := make([]byte, len(magic)+8+len(payload))
+	copy(msg, magic)
+	binary.LittleEndian.PutUint32(msg[len(magic):], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(msg[len(magic)+4:], uint32(typ))
+	copy(msg[len(magic)+8:], payload)
+
+	_, err := w.Write(msg)
+	return err
+}
+
+// ReadMessage reads a message in the IPC framing.
+func ReadMessage(r io.Reader) (MessageType, []byte, error) {
+	header := make([]byte, len(magic)+8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	if string(header[:len(magic)]) != magic {
+		return 0, nil, errors.New("invalid magic string")
+	}
+
+	length := binary.LittleEndian.Uint32(header[len(magic):])
+	typ := MessageType(binary.LittleEndian.Uint32(header[len(magic)+4:]))
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+
+	return typ, payload, nil
+}
+
+// Node is a node of the layout tree.
+type Node struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Focused bool   `json:"focused"`
+	// AppID is the Wayland app ID of the window. It is only set by sway.
+	AppID *string `json:"app_id"`
+	// Window is the X11 window ID of the window, or 0 if it is not an X11
+	// window.
+	Window           int64             `json:"window"`
+	WindowProperties *WindowProperties `json:"window_properties"`
+	Nodes            []*Node           `json:"nodes"`
+	FloatingNodes    []*Node           `json:"floating_nodes"`
+}
+
+// WindowProperties contains the X11 properties of a window.
+type WindowProperties struct {
+	Class    string `json:"class"`
+	Instance string `json:"instance"`
+	Title    string `json:"title"`
+}
+
+// IsWindow returns true if the node is a window.
+func (n *Node) IsWindow() bool {
+	return (n.Type == "con" || n.Type == "floating_con") && (n.AppID != nil || n.Window != 0)
+}
+
+// Class returns the app ID of a Wayland window or the class of an X11 window.
+func (n *Node) Class() string {
+	if n.AppID != nil && *n.AppID != "" {
+		return *n.AppID
+	}
+	if n.WindowProperties != nil {
+		return n.WindowProperties.Class
+	}
+	return ""
+}
+
+// Window is a window in the layout tree.
+type Window struct {
+	*Node
+	// Workspace is the name of the workspace of the window. Windows in the
+	// scratchpad are on the __i3_scratch workspace.
+	Workspace string
+}
+
+// Windows returns every window in the tree, in the order of the tree.
+func (n *Node) Windows() []Window {
+	var windows []Window
+	n.appendWindows(&windows, "")
+	return windows
+}
+
+func (n *Node) appendWindows(windows *[]Window, workspace string) {
+	if n.Type == "workspace" {
+		workspace = n.Name
+	}
+
+	if n.IsWindow() {
+		*windows = append(*windows, Window{Node: n, Workspace: workspace})
+	}
+
+	for _, child := range n.Nodes {
+		child.appendWindows(windows, workspace)
+	}
+	for _, child := range n.FloatingNodes {
+		child.appendWindows(windows, workspace)
+	}
+}
+
+// GetTree returns the layout tree.
+func (c *Conn) GetTree() (*Node, error) {
+	reply, err := c.Send(GetTree, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree Node
+	if err := json.Unmarshal(reply, &tree); err != nil {
+		return nil, errors.Wrap(err, "failed to decode tree")
+	}
+
+	return &tree, nil
+}
+
+// CommandError is the error of commands that the compositor rejected, such as
+// commands for a window that was closed in the meantime.
+type CommandError struct {
+	Command  string
+	Failures []string
+}
+
+func (err *CommandError) Error() string {
+	return fmt.Sprintf("command %q failed: %s", err.Command, strings.Join(err.Failures, "; "))
+}
+
+// RunCommand runs the given commands. A *CommandError is returned if any of
+// them failed.
+func (c *Conn) RunCommand(command string) error {
+	reply, err := c.Send(RunCommand, []byte(command))
+	if err != nil {
+		return err
+	}
+
+	var results []struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+
+	if err := json.Unmarshal(reply, &results); err != nil {
+		return errors.Wrap(err, "failed to decode command results")
+	}
+
+	var failures []string
+	for _, result := range results {
+		if !result.Success {
+			failures = append(failures, result.Error)
+		}
+	}
+
+	if len(failures) > 0 {
+		return &CommandError{Command: command, Failures: failures}
+	}
+
+	return nil
+}
+
+// Focus focuses the node with the given ID.
+func (c *Conn) Focus(id int64) error {
+	return c.RunCommand(fmt.Sprintf("[con_id=%d] focus", id))
+}
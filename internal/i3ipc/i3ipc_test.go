@@ -0,0 +1,198 @@
+package i3ipc
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+)
+
+const testTree = `{
+	"id": 1, "type": "root", "name": "root",
+	"nodes": [{
+		"id": 2, "type": "output", "name": "eDP-1",
+		"nodes": [{
+			"id": 3, "type": "workspace", "name": "1",
+			"nodes": [
+				{"id": 10, "type": "con", "name": "Inbox - Mozilla Firefox", "app_id": "firefox", "nodes": []},
+				{"id": 11, "type": "con", "name": "", "nodes": [
+					{"id": 12, "type": "con", "name": "~/src", "app_id": "foot", "nodes": []}
+				]}
+			],
+			"floating_nodes": [
+				{"id": 13, "type": "floating_con", "name": "Steam", "app_id": null, "window": 4194305,
+				 "window_properties": {"class": "Steam", "instance": "Steam", "title": "Steam"}, "nodes": []}
+			]
+		}]
+	}, {
+		"id": 4, "type": "output", "name": "__i3",
+		"nodes": [{
+			"id": 5, "type": "workspace", "name": "__i3_scratch",
+			"floating_nodes": [
+				{"id": 14, "type": "floating_con", "name": "htop", "app_id": "foot", "nodes": []}
+			]
+		}]
+	}]
+}`
+
+// fakeServer is a stand-in IPC socket that replies to get_tree with testTree
+// and records the commands that it runs and the connections that it accepts.
+// Commands for the container 99 fail.
+type fakeServer struct {
+	listener    net.Listener
+	mutex       sync.Mutex
+	commands    []string
+	connections int
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "ipc.sock"))
+	if err != nil {
+		t.Fatal("cannot listen:", err)
+	}
+
+	s := &fakeServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			s.mutex.Lock()
+			s.connections++
+			s.mutex.Unlock()
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeServer) path() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		typ, payload, err := ReadMessage(conn)
+		if err != nil {
+			return
+		}
+
+		var reply string
+
+		switch typ {
+		case GetTree:
+			reply = testTree
+		case RunCommand:
+			s.mutex.Lock()
+			s.commands = append(s.commands, string(payload))
+			s.mutex.Unlock()
+
+			if strings.Contains(string(payload), "con_id=99") {
+				reply = `[{"success": false, "error": "No matching node"}]`
+			} else {
+				reply = `[{"success": true}]`
+			}
+		default:
+			reply = `{"success": false}`
+		}
+
+		if err := WriteMessage(conn, typ, []byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func TestGetTree(t *testing.T) {
+	server := newFakeServer(t)
+
+	conn, err := Dial(server.path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	tree, err := conn.GetTree()
+	if err != nil {
+		t.Fatal("cannot get tree:", err)
+	}
+
+	var titles, classes, workspaces []string
+	for _, window := range tree.Windows() {
+		titles = append(titles, window.Name)
+		classes = append(classes, window.Class())
+		workspaces = append(workspaces, window.Workspace)
+	}
+
+	if expected := []string{"Inbox - Mozilla Firefox", "~/src", "Steam", "htop"}; !reflect.DeepEqual(titles, expected) {
+		t.Errorf("expected titles %q, got %q", expected, titles)
+	}
+	if expected := []string{"firefox", "foot", "Steam", "foot"}; !reflect.DeepEqual(classes, expected) {
+		t.Errorf("expected classes %q, got %q", expected, classes)
+	}
+	if expected := []string{"1", "1", "1", "__i3_scratch"}; !reflect.DeepEqual(workspaces, expected) {
+		t.Errorf("expected workspaces %q, got %q", expected, workspaces)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	server := newFakeServer(t)
+
+	appInfo := func(class string) (string, string) {
+		if class == "foot" {
+			return "Foot", "foot"
+		}
+		return "", ""
+	}
+
+	searcher := appindex.NewSubstringSearcher(false, appindex.DefaultWeights)
+	p := NewProvider(server.path(), searcher, appInfo)
+
+	items := p.Search("foot")
+
+	var subtitles []string
+	for _, item := range items {
+		subtitles = append(subtitles, item.Subtitle)
+	}
+
+	expected := []string{"Foot — workspace 1", "Foot — scratchpad"}
+	if !reflect.DeepEqual(subtitles, expected) {
+		t.Fatalf("expected subtitles %q, got %q", expected, subtitles)
+	}
+
+	if items := p.Search("steam"); len(items) != 1 || items[0].Icon != "steam" {
+		t.Fatalf("unexpected items for steam: %+v", items)
+	}
+
+	// Break the connection, which the provider should recover from.
+	p.conn.Close()
+
+	items[1].Activate()
+
+	// A rejected command neither reconnects nor runs again.
+	p.focus(99)
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if expected := []string{"[con_id=14] focus", "[con_id=99] focus"}; !reflect.DeepEqual(server.commands, expected) {
+		t.Errorf("expected commands %q, got %q", expected, server.commands)
+	}
+
+	if server.connections != 2 {
+		t.Errorf("expected 2 connections, got %d", server.connections)
+	}
+}
@@ -0,0 +1,185 @@
+package i3ipc
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+	"github.com/pkg/errors"
+)
+
+const scratchpad = "__i3_scratch"
+
+// Provider is the appindex.Provider of the open windows. Activating a window
+// focuses it. The IPC socket is connected to lazily and reconnected to if the
+// connection breaks. The windows are listed once per Refresh rather than on
+// every search.
+type Provider struct {
+	path     string
+	searcher appindex.Searcher
+	appInfo  func(class string) (name, icon string)
+
+	mutex sync.Mutex
+	conn  *Conn
+	// listed is true once the windows below are listed.
+	listed  bool
+	windows []Window
+	apps    []string
+	icons   []string
+}
+
+// NewProvider creates a new window provider that connects to the IPC socket at
+// the given path. The searcher searches the records of the windows, which have
+// the title as the name, the application name as the description and the app
+// ID or class as the ID. appInfo returns the name and icon of the application
+// of a window from its app ID or class. It is optional, and either value may be
+// empty.
+func NewProvider(path string, searcher appindex.Searcher, appInfo func(class string) (name, icon string)) *Provider {
+	return &Provider{
+		path:     path,
+		searcher: searcher,
+		appInfo:  appInfo,
+	}
+}
+
+// Refresh lists the windows again in the background, such as when the launcher
+// is opened. Searches wait for it to finish. Until the first refresh, the
+// windows are listed by the first search.
+func (p *Provider) Refresh() {
+	go func() {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+
+		p.list()
+	}()
+}
+
+// Search implements appindex.Provider.
+func (p *Provider) Search(query string) []appindex.Item {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.listed {
+		p.list()
+	}
+
+	matches := p.searcher.Search(query)
+
+	items := make([]appindex.Item, len(matches))
+	for i, match := range matches {
+		window := p.windows[match.Index]
+		id := window.ID
+
+		subtitle := p.apps[match.Index]
+		if window.Workspace == scratchpad {
+			subtitle += " — scratchpad"
+		} else if window.Workspace != "" {
+			subtitle += " — workspace " + window.Workspace
+		}
+
+		items[i] = appindex.Item{
+			ID:          strconv.FormatInt(id, 10),
+			Title:       window.Name,
+			Subtitle:    subtitle,
+			Icon:        p.icons[match.Index],
+			Score:       match.Score,
+			TitleRanges: match.Ranges[appindex.FieldName],
+			// The subtitle starts with the application name, so its ranges
+			// still apply.
+			SubtitleRanges: match.Ranges[appindex.FieldDescription],
+			Class:          "window",
+			Activate:       func() { p.focus(id) },
+		}
+	}
+
+	return items
+}
+
+// list lists and indexes the windows. If they cannot be listed, then there are
+// none until the next refresh, so that an unresponsive compositor is not asked
+// again on every search. The mutex must be held.
+func (p *Provider) list() {
+	p.listed = true
+
+	var windows []Window
+
+	tree, err := p.getTree()
+	if err != nil {
+		log.Println("cannot list windows:", err)
+	} else {
+		windows = tree.Windows()
+	}
+
+	records := make([]appindex.Record, len(windows))
+	apps := make([]string, len(windows))
+	icons := make([]string, len(windows))
+
+	for i, window := range windows {
+		class := window.Class()
+
+		if p.appInfo != nil {
+			apps[i], icons[i] = p.appInfo(class)
+		}
+		if apps[i] == "" {
+			apps[i] = class
+		}
+		if icons[i] == "" {
+			icons[i] = strings.ToLower(class)
+		}
+
+		records[i][appindex.FieldName] = window.Name
+		records[i][appindex.FieldDescription] = apps[i]
+		records[i][appindex.FieldID] = class
+	}
+
+	p.searcher.Index(records)
+
+	p.windows = windows
+	p.apps = apps
+	p.icons = icons
+}
+
+func (p *Provider) focus(id int64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if err := p.withConn(func(conn *Conn) error { return conn.Focus(id) }); err != nil {
+		log.Println("cannot focus window:", err)
+	}
+}
+
+func (p *Provider) getTree() (tree *Node, err error) {
+	err = p.withConn(func(conn *Conn) error {
+		tree, err = conn.GetTree()
+		return err
+	})
+	return
+}
+
+// withConn calls f with the connection, connecting first if needed. If f
+// fails to talk to the compositor, then it is retried once with a new
+// connection, since the compositor may have been restarted. Commands that the
+// compositor rejects are not retried. The mutex must be held.
+func (p *Provider) withConn(f func(*Conn) error) error {
+	for retry := false; ; retry = true {
+		if p.conn == nil {
+			conn, err := Dial(p.path)
+			if err != nil {
+				return err
+			}
+			p.conn = conn
+		}
+
+		err := f(p.conn)
+
+		var cmdErr *CommandError
+		if err == nil || retry || errors.As(err, &cmdErr) {
+			return err
+		}
+
+		p.conn.Close()
+		p.conn = nil
+	}
+}
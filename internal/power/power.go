@@ -0,0 +1,140 @@
+// Package power provides the session and power actions, such as locking the
+// screen and rebooting.
+package power
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+)
+
+// Action is a session or power action.
+type Action struct {
+	// ID is the name of the command of the action in the config.
+	ID       string
+	Name     string
+	Keywords []string
+	Icon     string
+	// Dangerous is true if the action ends the session, so it may need to be
+	// confirmed.
+	Dangerous bool
+}
+
+// Actions are all actions in the order that they are shown.
+var Actions = []Action{
+	{
+		ID:       "lock",
+		Name:     "Lock",
+		Keywords: []string{"lock screen", "screensaver"},
+		Icon:     "system-lock-screen",
+	},
+	{
+		ID:        "log-out",
+		Name:      "Log Out",
+		Keywords:  []string{"logout", "sign out", "exit", "session"},
+		Icon:      "system-log-out",
+		Dangerous: true,
+	},
+	{
+		ID:       "suspend",
+		Name:     "Suspend",
+		Keywords: []string{"sleep", "standby"},
+		Icon:     "system-suspend",
+	},
+	{
+		ID:       "hibernate",
+		Name:     "Hibernate",
+		Keywords: []string{"sleep", "suspend to disk"},
+		Icon:     "system-suspend-hibernate",
+	},
+	{
+		ID:        "reboot",
+		Name:      "Reboot",
+		Keywords:  []string{"restart"},
+		Icon:      "system-reboot",
+		Dangerous: true,
+	},
+	{
+		ID:        "power-off",
+		Name:      "Power Off",
+		Keywords:  []string{"shutdown", "shut down", "poweroff", "halt"},
+		Icon:      "system-shutdown",
+		Dangerous: true,
+	},
+}
+
+// weights are the weights of the records of actions.
+var weights = appindex.Weights{
+	appindex.FieldName:     1,
+	appindex.FieldKeywords: 0.6,
+}
+
+// Provider is the appindex.Provider of the session and power actions.
+type Provider struct {
+	actions  []Action
+	commands map[string]string
+	confirm  bool
+	run      func(command string)
+
+	mutex    sync.Mutex
+	searcher appindex.Searcher
+}
+
+// NewProvider creates a new provider of the actions that have commands. The
+// commands are looked up by the IDs of the actions. If confirm is true, then
+// dangerous actions need to be confirmed. run runs a command.
+func NewProvider(commands map[string]string, confirm bool, run func(command string)) *Provider {
+	p := &Provider{
+		commands: commands,
+		confirm:  confirm,
+		run:      run,
+		searcher: appindex.NewSubstringSearcher(false, weights),
+	}
+
+	for _, action := range Actions {
+		if commands[action.ID] != "" {
+			p.actions = append(p.actions, action)
+		}
+	}
+
+	records := make([]appindex.Record, len(p.actions))
+	for i, action := range p.actions {
+		records[i][appindex.FieldName] = action.Name
+		records[i][appindex.FieldKeywords] = strings.Join(action.Keywords, " ")
+	}
+
+	p.searcher.Index(records)
+	return p
+}
+
+// Search implements appindex.Provider.
+func (p *Provider) Search(query string) []appindex.Item {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	matches := p.searcher.Search(query)
+
+	items := make([]appindex.Item, len(matches))
+	for i, match := range matches {
+		action := p.actions[match.Index]
+		command := p.commands[action.ID]
+
+		items[i] = appindex.Item{
+			ID:          action.ID,
+			Title:       action.Name,
+			Subtitle:    command,
+			Icon:        action.Icon,
+			Score:       match.Score,
+			TitleRanges: match.Ranges[appindex.FieldName],
+			Class:       "power",
+			Activate:    func() { p.run(command) },
+		}
+
+		if p.confirm && action.Dangerous {
+			items[i].Confirm = "Are you sure you want to " + strings.ToLower(action.Name) + "?"
+		}
+	}
+
+	return items
+}
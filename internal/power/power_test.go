@@ -0,0 +1,41 @@
+package power
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProvider(t *testing.T) {
+	commands := map[string]string{
+		"lock":      "loginctl lock-session",
+		"reboot":    "systemctl reboot",
+		"power-off": "systemctl poweroff",
+	}
+
+	var ran []string
+	p := NewProvider(commands, true, func(command string) { ran = append(ran, command) })
+
+	if items := p.Search("suspend"); len(items) != 0 {
+		t.Errorf("expected no action without a command, got %+v", items)
+	}
+
+	items := p.Search("shutdown")
+	if len(items) != 1 || items[0].Title != "Power Off" || items[0].Confirm == "" {
+		t.Fatalf("unexpected items for shutdown: %+v", items)
+	}
+
+	items[0].Activate()
+
+	if lock := p.Search("lock"); len(lock) != 1 || lock[0].Confirm != "" {
+		t.Errorf("expected lock without confirmation, got %+v", lock)
+	}
+
+	if expected := []string{"systemctl poweroff"}; !reflect.DeepEqual(ran, expected) {
+		t.Errorf("expected to run %q, got %q", expected, ran)
+	}
+
+	p = NewProvider(commands, false, nil)
+	if items := p.Search("reboot"); len(items) != 1 || items[0].Confirm != "" {
+		t.Errorf("expected reboot without confirmation, got %+v", items)
+	}
+}
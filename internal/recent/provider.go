@@ -0,0 +1,137 @@
+package recent
+
+import (
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+)
+
+const maxItems = 20
+
+// Provider is the appindex.Provider that searches the names of the recently
+// used files. The files are read again whenever the file changes.
+type Provider struct {
+	file *File
+	open func(uri string)
+	icon func(mimeType string) string
+
+	mutex     sync.Mutex
+	searcher  appindex.Searcher
+	bookmarks []Bookmark
+	locations []string
+}
+
+// NewProvider creates a new provider of the recently used files in the XBEL
+// file at the given path. The searcher searches the records of the files, which
+// have the name as the name and the directory as the description. open opens
+// the URI of a file or directory, and icon returns the icon of a MIME type.
+func NewProvider(path string, searcher appindex.Searcher, open func(uri string), icon func(mimeType string) string) *Provider {
+	return &Provider{
+		file:     NewFile(path),
+		open:     open,
+		icon:     icon,
+		searcher: searcher,
+	}
+}
+
+// Search implements appindex.Provider.
+func (p *Provider) Search(query string) []appindex.Item {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	bookmarks, updated, err := p.file.Bookmarks()
+	if err != nil {
+		log.Println("cannot read recent files:", err)
+	}
+	if updated {
+		p.index(bookmarks)
+	}
+
+	var items []appindex.Item
+
+	for _, match := range p.searcher.Search(query) {
+		bookmark := &p.bookmarks[match.Index]
+
+		// Skip the files that were deleted since they were used.
+		if path := bookmark.Path(); path != "" {
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+		}
+
+		items = append(items, p.item(bookmark, p.locations[match.Index], match))
+		if len(items) == maxItems {
+			break
+		}
+	}
+
+	return items
+}
+
+// index indexes the bookmarks that aren't private to the applications that
+// used them.
+func (p *Provider) index(bookmarks []Bookmark) {
+	p.bookmarks = p.bookmarks[:0]
+	p.locations = p.locations[:0]
+
+	home, _ := os.UserHomeDir()
+
+	for _, bookmark := range bookmarks {
+		if bookmark.Private {
+			continue
+		}
+
+		location := bookmark.URI
+		if path := bookmark.Path(); path != "" {
+			location = filepath.Dir(path)
+			if home != "" && (location == home || strings.HasPrefix(location, home+"/")) {
+				location = "~" + strings.TrimPrefix(location, home)
+			}
+		}
+
+		p.bookmarks = append(p.bookmarks, bookmark)
+		p.locations = append(p.locations, location)
+	}
+
+	records := make([]appindex.Record, len(p.bookmarks))
+	for i, bookmark := range p.bookmarks {
+		records[i][appindex.FieldName] = bookmark.Name()
+		records[i][appindex.FieldDescription] = p.locations[i]
+	}
+
+	p.searcher.Index(records)
+}
+
+func (p *Provider) item(bookmark *Bookmark, location string, match appindex.Match) appindex.Item {
+	uri := bookmark.URI
+
+	item := appindex.Item{
+		ID:             uri,
+		Title:          bookmark.Name(),
+		Subtitle:       location,
+		Icon:           p.icon(bookmark.MIMEType),
+		Score:          match.Score,
+		TitleRanges:    match.Ranges[appindex.FieldName],
+		SubtitleRanges: match.Ranges[appindex.FieldDescription],
+		Class:          "recent-file",
+		Activate:       func() { p.open(uri) },
+	}
+
+	if path := bookmark.Path(); path != "" {
+		dir := (&url.URL{Scheme: "file", Path: filepath.Dir(path)}).String()
+
+		item.Actions = func() []appindex.Item {
+			return []appindex.Item{
+				{ID: uri, Title: "Open", Activate: func() { p.open(uri) }},
+				{ID: dir, Title: "Open Containing Folder", Activate: func() { p.open(dir) }},
+			}
+		}
+	}
+
+	return item
+}
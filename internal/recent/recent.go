@@ -0,0 +1,260 @@
+// Package recent parses the recently used files store of GTK, which is an XBEL
+// file at $XDG_DATA_HOME/recently-used.xbel.
+package recent
+
+import (
+	"encoding/xml"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	bookmarkNS = "http://www.freedesktop.org/standards/desktop-bookmarks"
+	mimeNS     = "http://www.freedesktop.org/standards/shared-mime-info"
+)
+
+// Bookmark is a recently used file.
+type Bookmark struct {
+	URI         string
+	Title       string
+	Description string
+	Added       time.Time
+	Modified    time.Time
+	Visited     time.Time
+	MIMEType    string
+	Groups      []string
+	// Applications contains the applications that opened the file.
+	Applications []Application
+	// Private is true if the file should only be shown to the applications
+	// that opened it.
+	Private bool
+}
+
+// Application is an application that opened a recently used file.
+type Application struct {
+	Name     string
+	Exec     string
+	Modified time.Time
+	// Count is the number of times that the application opened the file.
+	Count int
+}
+
+// Path returns the local path of the file, or an empty string if the URI is
+// not a file URI.
+func (b *Bookmark) Path() string {
+	u, err := url.Parse(b.URI)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return u.Path
+}
+
+// Name returns the name of the file to be displayed, which is its title or the
+// last element of its URI.
+func (b *Bookmark) Name() string {
+	if b.Title != "" {
+		return b.Title
+	}
+
+	u, err := url.Parse(b.URI)
+	if err != nil {
+		return b.URI
+	}
+
+	if name := path.Base(u.Path); name != "/" && name != "." {
+		return name
+	}
+
+	return b.URI
+}
+
+// LastUsed returns the last time that the file was used by any application.
+func (b *Bookmark) LastUsed() time.Time {
+	last := b.Modified
+	if b.Visited.After(last) {
+		last = b.Visited
+	}
+	for _, app := range b.Applications {
+		if app.Modified.After(last) {
+			last = app.Modified
+		}
+	}
+	return last
+}
+
+type xbel struct {
+	Bookmarks []xbelBookmark `xml:"bookmark"`
+}
+
+type xbelBookmark struct {
+	Href        string `xml:"href,attr"`
+	Added       string `xml:"added,attr"`
+	Modified    string `xml:"modified,attr"`
+	Visited     string `xml:"visited,attr"`
+	Title       string `xml:"title"`
+	Description string `xml:"desc"`
+	Metadata    []struct {
+		Owner    string `xml:"owner,attr"`
+		MIMEType struct {
+			Type string `xml:"type,attr"`
+		} `xml:"http://www.freedesktop.org/standards/shared-mime-info mime-type"`
+		Groups       []string `xml:"http://www.freedesktop.org/standards/desktop-bookmarks groups>group"`
+		Applications []struct {
+			Name     string `xml:"name,attr"`
+			Exec     string `xml:"exec,attr"`
+			Modified string `xml:"modified,attr"`
+			// Stamp is the modification time in seconds written by older
+			// versions of GLib.
+			Stamp string `xml:"timestamp,attr"`
+			Count int    `xml:"count,attr"`
+		} `xml:"http://www.freedesktop.org/standards/desktop-bookmarks applications>application"`
+		Private *struct{} `xml:"http://www.freedesktop.org/standards/desktop-bookmarks private"`
+	} `xml:"info>metadata"`
+}
+
+// Parse parses the XBEL file from the given reader. The bookmarks are sorted by
+// when they were last used, most recent first.
+func Parse(r io.Reader) ([]Bookmark, error) {
+	var doc xbel
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, errors.Wrap(err, "failed to decode XBEL")
+	}
+
+	bookmarks := make([]Bookmark, 0, len(doc.Bookmarks))
+
+	for _, b := range doc.Bookmarks {
+		if b.Href == "" {
+			continue
+		}
+
+		bookmark := Bookmark{
+			URI:         b.Href,
+			Title:       b.Title,
+			Description: b.Description,
+			Added:       parseTime(b.Added),
+			Modified:    parseTime(b.Modified),
+			Visited:     parseTime(b.Visited),
+		}
+
+		for _, metadata := range b.Metadata {
+			if bookmark.MIMEType == "" {
+				bookmark.MIMEType = metadata.MIMEType.Type
+			}
+
+			bookmark.Groups = append(bookmark.Groups, metadata.Groups...)
+			bookmark.Private = bookmark.Private || metadata.Private != nil
+
+			for _, app := range metadata.Applications {
+				modified := parseTime(app.Modified)
+				if modified.IsZero() && app.Stamp != "" {
+					if stamp, err := strconv.ParseInt(app.Stamp, 10, 64); err == nil {
+						modified = time.Unix(stamp, 0)
+					}
+				}
+
+				bookmark.Applications = append(bookmark.Applications, Application{
+					Name:     app.Name,
+					Exec:     app.Exec,
+					Modified: modified,
+					Count:    app.Count,
+				})
+			}
+		}
+
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	sort.SliceStable(bookmarks, func(i, j int) bool {
+		return bookmarks[i].LastUsed().After(bookmarks[j].LastUsed())
+	})
+
+	return bookmarks, nil
+}
+
+// parseTime parses a time in the ISO 8601 format used by GLib. The zero time
+// is returned if the time is invalid.
+func parseTime(str string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, str)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// DefaultPath returns the path to the recently used files of the user.
+func DefaultPath() (string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get home directory")
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(data, "recently-used.xbel"), nil
+}
+
+// File is an XBEL file that is only parsed again once its modification time
+// changes. All its methods are thread-safe.
+type File struct {
+	path string
+
+	mutex     sync.Mutex
+	modTime   time.Time
+	bookmarks []Bookmark
+}
+
+// NewFile creates a new file for the XBEL file at the given path.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Bookmarks returns the bookmarks in the file. The file is parsed again if it
+// changed since the last call, in which case updated is true. A file that does
+// not exist has no bookmarks. The returned slice must not be modified.
+func (f *File) Bookmarks() (bookmarks []Bookmark, updated bool, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	s, err := os.Stat(f.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return f.bookmarks, false, errors.Wrap(err, "failed to stat recent files")
+		}
+
+		updated = f.bookmarks != nil
+		f.bookmarks = nil
+		f.modTime = time.Time{}
+		return nil, updated, nil
+	}
+
+	if s.ModTime().Equal(f.modTime) {
+		return f.bookmarks, false, nil
+	}
+
+	r, err := os.Open(f.path)
+	if err != nil {
+		return f.bookmarks, false, errors.Wrap(err, "failed to open recent files")
+	}
+	defer r.Close()
+
+	bookmarks, err = Parse(r)
+	if err != nil {
+		return f.bookmarks, false, err
+	}
+
+	f.bookmarks = bookmarks
+	f.modTime = s.ModTime()
+
+	return bookmarks, true, nil
+}
@@ -0,0 +1,177 @@
+package recent
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+)
+
+const testXBEL = `<?xml version="1.0" encoding="UTF-8"?>
+<xbel version="1.0"
+      xmlns:bookmark="http://www.freedesktop.org/standards/desktop-bookmarks"
+      xmlns:mime="http://www.freedesktop.org/standards/shared-mime-info"
+>
+  <bookmark href="file:///home/user/Documents/report%202022.pdf" added="2022-03-01T10:00:00.123456Z" modified="2022-03-01T10:00:00.123456Z" visited="2022-03-01T10:00:00Z">
+    <info>
+      <metadata owner="http://freedesktop.org">
+        <mime:mime-type type="application/pdf"/>
+        <bookmark:groups>
+          <bookmark:group>Documents</bookmark:group>
+        </bookmark:groups>
+        <bookmark:applications>
+          <bookmark:application name="Evince" exec="&apos;evince %u&apos;" modified="2022-03-02T08:30:00Z" count="2"/>
+        </bookmark:applications>
+      </metadata>
+    </info>
+  </bookmark>
+  <bookmark href="file:///home/user/notes.txt" added="2022-03-04T10:00:00Z" modified="2022-03-04T10:00:00Z" visited="2022-03-04T10:00:00Z">
+    <title>My Notes</title>
+    <info>
+      <metadata owner="http://freedesktop.org">
+        <mime:mime-type type="text/plain"/>
+        <bookmark:applications>
+          <bookmark:application name="gedit" exec="&apos;gedit %u&apos;" timestamp="1646388000" count="1"/>
+        </bookmark:applications>
+        <bookmark:private/>
+      </metadata>
+    </info>
+  </bookmark>
+  <bookmark href="https://example.com/" added="2022-02-01T10:00:00Z" modified="2022-02-01T10:00:00Z" visited="2022-02-01T10:00:00Z">
+  </bookmark>
+</xbel>
+`
+
+func TestParse(t *testing.T) {
+	bookmarks, err := Parse(strings.NewReader(testXBEL))
+	if err != nil {
+		t.Fatal("cannot parse:", err)
+	}
+
+	var uris []string
+	for _, b := range bookmarks {
+		uris = append(uris, b.URI)
+	}
+
+	expected := []string{
+		"file:///home/user/notes.txt",
+		"file:///home/user/Documents/report%202022.pdf",
+		"https://example.com/",
+	}
+	if !reflect.DeepEqual(uris, expected) {
+		t.Fatalf("expected %q, got %q", expected, uris)
+	}
+
+	notes := bookmarks[0]
+	if notes.Name() != "My Notes" || notes.MIMEType != "text/plain" || !notes.Private {
+		t.Errorf("unexpected notes bookmark %+v", notes)
+	}
+	if len(notes.Applications) != 1 || !notes.Applications[0].Modified.Equal(time.Unix(1646388000, 0)) {
+		t.Errorf("unexpected notes applications %+v", notes.Applications)
+	}
+
+	report := bookmarks[1]
+	if name := report.Name(); name != "report 2022.pdf" {
+		t.Errorf("unexpected report name %q", name)
+	}
+	if path := report.Path(); path != "/home/user/Documents/report 2022.pdf" {
+		t.Errorf("unexpected report path %q", path)
+	}
+	if !reflect.DeepEqual(report.Groups, []string{"Documents"}) || report.Private {
+		t.Errorf("unexpected report bookmark %+v", report)
+	}
+
+	expectedApp := Application{
+		Name:     "Evince",
+		Exec:     "'evince %u'",
+		Modified: time.Date(2022, 3, 2, 8, 30, 0, 0, time.UTC),
+		Count:    2,
+	}
+	if len(report.Applications) != 1 || !reflect.DeepEqual(report.Applications[0], expectedApp) {
+		t.Errorf("expected applications [%+v], got %+v", expectedApp, report.Applications)
+	}
+
+	if web := bookmarks[2]; web.Path() != "" || web.Name() != "https://example.com/" {
+		t.Errorf("unexpected path %q or name %q of web bookmark", web.Path(), web.Name())
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recently-used.xbel")
+	f := NewFile(path)
+
+	if bookmarks, updated, err := f.Bookmarks(); err != nil || updated || len(bookmarks) > 0 {
+		t.Fatalf("unexpected result for missing file: %v, %v, %v", bookmarks, updated, err)
+	}
+
+	if err := os.WriteFile(path, []byte(testXBEL), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if bookmarks, updated, err := f.Bookmarks(); err != nil || !updated || len(bookmarks) != 3 {
+		t.Fatalf("unexpected result for new file: %d bookmarks, %v, %v", len(bookmarks), updated, err)
+	}
+
+	if _, updated, _ := f.Bookmarks(); updated {
+		t.Error("unchanged file was parsed again")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if bookmarks, updated, err := f.Bookmarks(); err != nil || !updated || len(bookmarks) > 0 {
+		t.Fatalf("unexpected result for removed file: %v, %v, %v", bookmarks, updated, err)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	dir := t.TempDir()
+
+	report := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(report, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	xbel := `<?xml version="1.0" encoding="UTF-8"?>
+<xbel version="1.0" xmlns:mime="http://www.freedesktop.org/standards/shared-mime-info">
+  <bookmark href="file://` + report + `" added="2022-03-01T10:00:00Z" modified="2022-03-01T10:00:00Z" visited="2022-03-01T10:00:00Z">
+    <info><metadata owner="http://freedesktop.org"><mime:mime-type type="application/pdf"/></metadata></info>
+  </bookmark>
+  <bookmark href="file://` + filepath.Join(dir, "deleted.pdf") + `" added="2022-03-01T10:00:00Z" modified="2022-03-01T10:00:00Z" visited="2022-03-01T10:00:00Z">
+  </bookmark>
+</xbel>
+`
+
+	path := filepath.Join(dir, "recently-used.xbel")
+	if err := os.WriteFile(path, []byte(xbel), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var opened []string
+	open := func(uri string) { opened = append(opened, uri) }
+	icon := func(mimeType string) string { return "icon " + mimeType }
+
+	p := NewProvider(path, appindex.NewSubstringSearcher(false, appindex.DefaultWeights), open, icon)
+
+	if items := p.Search("deleted"); len(items) != 0 {
+		t.Errorf("unexpected items for a deleted file: %v", items)
+	}
+
+	items := p.Search("report")
+	if len(items) != 1 || items[0].Title != "report.pdf" || items[0].Icon != "icon application/pdf" {
+		t.Fatalf("unexpected items: %v", items)
+	}
+
+	items[0].Activate()
+	items[0].Actions()[1].Activate()
+
+	expected := []string{"file://" + report, "file://" + dir}
+	if !reflect.DeepEqual(opened, expected) {
+		t.Errorf("expected to open %q, got %q", expected, opened)
+	}
+}
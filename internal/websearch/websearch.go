@@ -0,0 +1,215 @@
+// Package websearch provides results that open URLs and search the web.
+package websearch
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+)
+
+// Engine is a web search engine.
+type Engine struct {
+	// Name is the name of the engine that is shown.
+	Name string
+	// Keyword selects the engine when the query starts with it followed by a
+	// space, such as "ddg" in "ddg foo". It is optional.
+	Keyword string
+	// URL is the template of the search URL. Every %s is replaced with the
+	// escaped search terms.
+	URL string
+}
+
+// SearchURL returns the URL that searches the engine for the given terms.
+func (e Engine) SearchURL(terms string) string {
+	return strings.ReplaceAll(e.URL, "%s", url.QueryEscape(terms))
+}
+
+// schemes are the schemes of the queries that are always URLs.
+var schemes = []string{"http://", "https://", "ftp://", "file://", "mailto:"}
+
+// ParseURL returns the URL that the query is, and whether the query is a URL
+// with a scheme. A query without a scheme is a URL if it looks like a domain
+// name, optionally followed by a port and a path, in which case https:// is
+// prepended. An empty string is returned if the query isn't a URL.
+func ParseURL(query string) (uri string, explicit bool) {
+	query = strings.TrimSpace(query)
+	if query == "" || strings.ContainsAny(query, " \t\n") {
+		return "", false
+	}
+
+	lower := strings.ToLower(query)
+	for _, scheme := range schemes {
+		if strings.HasPrefix(lower, scheme) && len(query) > len(scheme) {
+			return query, true
+		}
+	}
+
+	host := query
+	if i := strings.IndexAny(host, "/?#"); i != -1 {
+		host = host[:i]
+	}
+
+	if i := strings.LastIndexByte(host, ':'); i != -1 {
+		if !isDigits(host[i+1:]) {
+			return "", false
+		}
+		host = host[:i]
+	}
+
+	if !isDomain(host) {
+		return "", false
+	}
+
+	return "https://" + query, false
+}
+
+// isDomain returns true if str looks like a domain name, which is either
+// localhost or at least two labels with a top-level domain of letters.
+func isDomain(str string) bool {
+	if strings.EqualFold(str, "localhost") {
+		return true
+	}
+
+	labels := strings.Split(str, ".")
+	if len(labels) < 2 {
+		return false
+	}
+
+	for _, label := range labels {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return false
+			}
+		}
+	}
+
+	tld := labels[len(labels)-1]
+	if len(tld) < 2 {
+		return false
+	}
+	for _, r := range tld {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasPrefixFold(str, prefix string) bool {
+	str = strings.TrimSpace(str)
+	return len(str) >= len(prefix) && strings.EqualFold(str[:len(prefix)], prefix)
+}
+
+func isDigits(str string) bool {
+	if str == "" {
+		return false
+	}
+	for _, r := range str {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Provider is the appindex.FallbackProvider of URLs and web searches. It offers
+// to open queries that are URLs and to search the engine whose keyword starts
+// the query. If no application matches the query, then it offers to search every
+// engine for it.
+type Provider struct {
+	engines []Engine
+	open    func(uri string)
+}
+
+// NewProvider creates a new provider of the given engines. open opens URLs.
+func NewProvider(engines []Engine, open func(uri string)) *Provider {
+	return &Provider{
+		engines: engines,
+		open:    open,
+	}
+}
+
+// Search implements appindex.Provider.
+func (p *Provider) Search(query string) []appindex.Item {
+	var items []appindex.Item
+
+	if uri, explicit := ParseURL(query); uri != "" {
+		item := p.openItem(uri, "Open "+strings.TrimSpace(query))
+		switch {
+		case explicit:
+			item.Score = appindex.TopScore
+		case hasPrefixFold(query, "www."):
+			item.Score = 1
+		default:
+			// Queries that merely look like domains are as likely to be file
+			// names such as main.go, so rank them below everything else.
+			item.Score = appindex.BottomScore
+		}
+		items = append(items, item)
+	}
+
+	if engine, terms, ok := p.keywordEngine(query); ok {
+		item := p.searchItem(engine, terms)
+		item.Score = appindex.TopScore
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// Fallback implements appindex.FallbackProvider.
+func (p *Provider) Fallback(query string) []appindex.Item {
+	terms := strings.TrimSpace(query)
+	if terms == "" {
+		return nil
+	}
+
+	items := make([]appindex.Item, len(p.engines))
+	for i, engine := range p.engines {
+		items[i] = p.searchItem(engine, terms)
+	}
+
+	return items
+}
+
+// keywordEngine returns the engine whose keyword starts the query and the rest
+// of the query.
+func (p *Provider) keywordEngine(query string) (Engine, string, bool) {
+	query = strings.TrimLeftFunc(query, unicode.IsSpace)
+
+	for _, engine := range p.engines {
+		if engine.Keyword == "" || !strings.HasPrefix(query, engine.Keyword+" ") {
+			continue
+		}
+
+		if terms := strings.TrimSpace(query[len(engine.Keyword):]); terms != "" {
+			return engine, terms, true
+		}
+	}
+
+	return Engine{}, "", false
+}
+
+func (p *Provider) searchItem(engine Engine, terms string) appindex.Item {
+	item := p.openItem(engine.SearchURL(terms), "Search "+engine.Name+" for “"+terms+"”")
+	item.Icon = "system-search"
+	item.Class = "web-search"
+	return item
+}
+
+func (p *Provider) openItem(uri, title string) appindex.Item {
+	return appindex.Item{
+		ID:       uri,
+		Title:    title,
+		Subtitle: uri,
+		Icon:     "web-browser",
+		Class:    "url",
+		Activate: func() { p.open(uri) },
+	}
+}
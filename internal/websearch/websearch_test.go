@@ -0,0 +1,91 @@
+package websearch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		query    string
+		uri      string
+		explicit bool
+	}{
+		{"https://example.com/a b", "", false},
+		{"https://example.com/foo?q=1", "https://example.com/foo?q=1", true},
+		{"HTTP://EXAMPLE.COM", "HTTP://EXAMPLE.COM", true},
+		{"mailto:someone@example.com", "mailto:someone@example.com", true},
+		{"example.com", "https://example.com", false},
+		{" www.example.co.uk/path#top ", "https://www.example.co.uk/path#top", false},
+		{"localhost:8080/admin", "https://localhost:8080/admin", false},
+		{"xn--bcher-kva.example", "https://xn--bcher-kva.example", false},
+		{"example.com:http", "", false},
+		{"3.14", "", false},
+		{"1.2.3.4", "", false},
+		{"firefox", "", false},
+		{"foo..com", "", false},
+		{"-foo.com", "", false},
+		{"https://", "", false},
+	}
+
+	for _, test := range tests {
+		uri, explicit := ParseURL(test.query)
+		if uri != test.uri || explicit != test.explicit {
+			t.Errorf("ParseURL(%q): expected (%q, %v), got (%q, %v)",
+				test.query, test.uri, test.explicit, uri, explicit)
+		}
+	}
+}
+
+func TestProvider(t *testing.T) {
+	var opened []string
+
+	engines := []Engine{
+		{Name: "DuckDuckGo", Keyword: "ddg", URL: "https://duckduckgo.com/?q=%s"},
+		{Name: "GitHub", Keyword: "gh", URL: "https://github.com/search?q=%s"},
+	}
+
+	p := NewProvider(engines, func(uri string) { opened = append(opened, uri) })
+
+	items := p.Search("gh gotk4 layer shell")
+	if len(items) != 1 || items[0].Title != "Search GitHub for “gotk4 layer shell”" || items[0].Score != appindex.TopScore {
+		t.Fatalf("unexpected keyword items %+v", items)
+	}
+
+	items[0].Activate()
+
+	if expected := []string{"https://github.com/search?q=gotk4+layer+shell"}; !reflect.DeepEqual(opened, expected) {
+		t.Errorf("expected to open %q, got %q", expected, opened)
+	}
+
+	if items := p.Search("gh "); len(items) != 0 {
+		t.Errorf("unexpected items for a keyword without terms: %+v", items)
+	}
+
+	if items := p.Search("example.com"); len(items) != 1 || items[0].ID != "https://example.com" || items[0].Score >= appindex.TopScore {
+		t.Errorf("unexpected items for a domain: %+v", items)
+	}
+
+	if items := p.Search("main.go"); len(items) != 1 || items[0].Score != appindex.BottomScore {
+		t.Errorf("unexpected items for a file name: %+v", items)
+	}
+
+	if items := p.Search("www.example.com"); len(items) != 1 || items[0].Score <= appindex.BottomScore {
+		t.Errorf("unexpected items for a www domain: %+v", items)
+	}
+
+	var urls []string
+	for _, item := range p.Fallback("cats & dogs") {
+		urls = append(urls, item.ID)
+	}
+
+	expected := []string{
+		"https://duckduckgo.com/?q=cats+%26+dogs",
+		"https://github.com/search?q=cats+%26+dogs",
+	}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("expected fallback URLs %q, got %q", expected, urls)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"github.com/diamondburned/gappdash/internal/appindex"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+)
+
+// listView is the resultView for ListMode. Each row shows the icon, the title
+// and the subtitle of the item.
+type listView struct {
+	*gtk.ListBox
+	items    []appindex.Item
+	activate func(appindex.Item)
+
+	// menu keeps the shown actions menu alive.
+	menu *gtk.Menu
+}
+
+func newListView(activate func(appindex.Item)) *listView {
+	list := gtk.NewListBox()
+	list.SetActivateOnSingleClick(true)
+	list.SetVAlign(gtk.AlignStart)
+	list.SetSelectionMode(gtk.SelectionSingle)
+	list.Show()
+	addCSSClass(list, "app-list")
+
+	v := &listView{
+		ListBox:  list,
+		activate: activate,
+	}
+
+	list.ConnectRowActivated(func(row *gtk.ListBoxRow) {
+		activate(v.items[row.Index()])
+	})
+
+	return v
+}
+
+func (v *listView) SetItems(items []appindex.Item) {
+	v.items = items
+	removeChildren(&v.Container)
+
+	for i, item := range items {
+		icon := newItemIcon(item)
+
+		nameLabel := newHighlightedLabel(item.Title, item.TitleRanges)
+		nameLabel.SetXAlign(0)
+		singlelineLabel(nameLabel)
+		addCSSClass(nameLabel, "list-item-name")
+
+		labels := gtk.NewBox(gtk.OrientationVertical, 0)
+		labels.SetVAlign(gtk.AlignCenter)
+		labels.SetHExpand(true)
+		labels.Add(nameLabel)
+
+		if desc := item.Subtitle; desc != "" {
+			descLabel := newHighlightedLabel(desc, item.SubtitleRanges)
+			descLabel.SetXAlign(0)
+			descLabel.SetTooltipText(desc)
+			singlelineLabel(descLabel)
+			addCSSClass(descLabel, "list-item-description", "dim-label")
+
+			labels.Add(descLabel)
+		}
+
+		box := gtk.NewBox(gtk.OrientationHorizontal, 0)
+		box.Add(icon)
+		box.Add(labels)
+		addCSSClass(box, "list-item")
+
+		if item.Class != "" {
+			addCSSClass(box, item.Class)
+		}
+
+		row := gtk.NewListBoxRow()
+
+		// Rows have no window of their own, so they don't get button events.
+		evbox := gtk.NewEventBox()
+		evbox.AddEvents(int(gdk.ButtonPressMask))
+		evbox.Connect("button-press-event", func(event *gdk.Event) bool {
+			if event.AsButton().Button() != gdk.BUTTON_SECONDARY {
+				return false
+			}
+
+			v.SelectRow(row)
+			v.popupActions(row, event)
+			return true
+		})
+		evbox.Add(box)
+
+		row.Add(evbox)
+
+		v.Add(row)
+
+		if i == 0 {
+			v.SelectRow(row)
+		}
+	}
+
+	v.ShowAll()
+}
+
+func (v *listView) SelectedItem() (appindex.Item, bool) {
+	if row := v.SelectedRow(); row != nil {
+		return v.items[row.Index()], true
+	}
+	return appindex.Item{}, false
+}
+
+func (v *listView) SelectedWidget() gtk.Widgetter {
+	if row := v.SelectedRow(); row != nil {
+		return row
+	}
+	return nil
+}
+
+func (v *listView) MoveSelection(step selectionStep, count int) bool {
+	if len(v.items) == 0 || step == stepColumn {
+		return false
+	}
+
+	index := 0
+	lines := 1
+
+	if row := v.SelectedRow(); row != nil {
+		index = row.Index()
+		lines = pageLines(row)
+	}
+
+	v.SelectRow(v.RowAtIndex(moveIndex(index, len(v.items), 1, lines, step, count)))
+	return true
+}
+
+func (v *listView) ActivateSelected() {
+	if row := v.SelectedRow(); row != nil {
+		row.Activate()
+	}
+}
+
+func (v *listView) PopupSelectedActions() {
+	if row := v.SelectedRow(); row != nil {
+		v.popupActions(row, nil)
+	}
+}
+
+// popupActions shows the actions menu for the given row. If event is nil, then
+// the menu is shown below the row instead of at the pointer.
+func (v *listView) popupActions(row *gtk.ListBoxRow, event *gdk.Event) {
+	v.menu = newActionsMenu(v.items[row.Index()], v.activate)
+	if v.menu == nil {
+		return
+	}
+
+	if event != nil {
+		v.menu.PopupAtPointer(event)
+	} else {
+		v.menu.PopupAtWidget(row, gdk.GravitySouthWest, gdk.GravityNorthWest, nil)
+	}
+}
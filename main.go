@@ -1,13 +1,26 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/diamondburned/gappdash/internal/appindex"
+	"github.com/diamondburned/gappdash/internal/calc"
+	"github.com/diamondburned/gappdash/internal/commands"
 	"github.com/diamondburned/gappdash/internal/desktopentry"
+	"github.com/diamondburned/gappdash/internal/emoji"
+	"github.com/diamondburned/gappdash/internal/execpath"
+	"github.com/diamondburned/gappdash/internal/gioapp"
+	"github.com/diamondburned/gappdash/internal/i3ipc"
+	"github.com/diamondburned/gappdash/internal/power"
+	"github.com/diamondburned/gappdash/internal/recent"
+	"github.com/diamondburned/gappdash/internal/websearch"
 	"github.com/diamondburned/gotk4-layer-shell/pkg/gtklayershell"
 	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/gio/v2"
 	"github.com/diamondburned/gotk4/pkg/glib/v2"
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
 	"github.com/diamondburned/gotk4/pkg/pango"
@@ -16,8 +29,13 @@ import (
 const appID = "com.github.diamondburned.gappdash"
 
 func main() {
-	app := gtk.NewApplication(appID, 0)
+	desktopentry.SetLauncher(gioapp.Launch)
+
+	app := gtk.NewApplication(appID, gio.ApplicationHandlesCommandLine)
 	app.Connect("activate", activate)
+	app.ConnectCommandLine(func(cmdline *gio.ApplicationCommandLine) int {
+		return commandLine(app, cmdline.Arguments())
+	})
 
 	if code := app.Run(os.Args); code > 0 {
 		os.Exit(code)
@@ -26,8 +44,15 @@ func main() {
 
 var app struct {
 	*gtk.Application
-	cfg *Config
-	idx *appindex.Index
+	cfg  *Config
+	idx  *appindex.Index
+	apps *appindex.Apps
+
+	favorites *appindex.Favorites
+	// windows is nil unless windows are searched.
+	windows *i3ipc.Provider
+
+	executables execpath.Cache
 
 	// previously opened window
 	window *window
@@ -35,6 +60,31 @@ var app struct {
 	reindexing bool
 }
 
+// commandLine handles the arguments of an invocation, which may come from
+// another instance. It opens the window like activate and returns the exit
+// status.
+func commandLine(gapp *gtk.Application, args []string) int {
+	flags := flag.NewFlagSet(filepath.Base(args[0]), flag.ContinueOnError)
+	pickEmoji := flags.Bool("emoji", false, "open the emoji picker")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	activate(gapp)
+
+	if *pickEmoji {
+		if prefix := app.cfg.Providers.Emoji.Prefix; prefix != "" {
+			app.window.entry.SetText(prefix)
+			app.window.entry.SetPosition(-1)
+		} else {
+			log.Println("cannot open the emoji picker: it has no prefix in the config")
+		}
+	}
+
+	return 0
+}
+
 func activate(gapp *gtk.Application) {
 	if app.Application == nil {
 		glib.LogUseDefaultLogger()
@@ -58,29 +108,121 @@ func activate(gapp *gtk.Application) {
 			app.Hold()
 		}
 
-		var searcher appindex.Searcher
-		if cfg.App.Fuzzy {
-			searcher = appindex.NewFuzzySearcher()
+		app.apps = appindex.NewApps(cfg.App.NewSearcher())
+		app.apps.SortType = cfg.App.Sort.EntrySortType()
+		app.apps.MaxAge = cfg.App.IndexAge
+		app.apps.Hidden = cfg.App.Hidden
+		app.apps.Pinned = cfg.App.Pinned
+		app.apps.Extra = commands.DesktopEntries(cfg.CustomEntries(), cfg.Providers.Commands.Runner())
+
+		app.apps.OnUpdate(func() {
+			glib.IdleAdd(func() {
+				if app.window != nil {
+					app.window.refresh()
+				}
+			})
+		})
+
+		if path, err := userCacheFile("index"); err == nil {
+			app.apps.SnapshotPath = path
+		}
+
+		var loaded bool
+		if app.apps.SnapshotPath != "" {
+			err := app.apps.LoadSnapshot()
+			if err != nil && !os.IsNotExist(err) {
+				log.Println("discarding index snapshot:", err)
+			}
+			loaded = err == nil
+		}
+
+		if loaded {
+			// Show the snapshot right away and catch up in the background.
+			go app.apps.Refresh()
 		} else {
-			searcher = appindex.NewSubstringSearcher(cfg.App.CaseSensitive)
+			app.apps.Reindex()
+		}
+
+		if err := app.apps.Watch(); err != nil {
+			log.Println("cannot watch for new applications, using index-age:", err)
+		}
+
+		favoritesPath, err := desktopentry.StateFile("favorites")
+		if err != nil {
+			log.Println("favorites will not be saved:", err)
+		}
+
+		app.favorites, err = appindex.OpenFavorites(favoritesPath)
+		if err != nil {
+			log.Println("cannot load favorites:", err)
+		}
+
+		app.idx = appindex.NewIndex(app.apps)
+
+		if cfg.Providers.Calculator {
+			app.idx.Register(calc.NewProvider(copyToClipboard))
+		}
+
+		if cfg.Providers.Executables {
+			app.idx.Register(commands.NewExecutablesProvider(
+				cfg.Providers.Commands.Runner(), app.executables.Executables, app.apps.IsGraphical,
+			))
+		}
+
+		if cfg.Providers.RecentFiles {
+			if path, err := recent.DefaultPath(); err == nil {
+				searcher := cfg.App.NewSearcherWithWeights(recentWeights)
+				app.idx.Register(recent.NewProvider(path, searcher, openURI, mimeIcon))
+			} else {
+				log.Println("cannot search recent files:", err)
+			}
+		}
+
+		if cfg.Providers.Windows {
+			// Only sway and i3 are supported, so quietly skip the provider
+			// elsewhere.
+			if path, err := i3ipc.SocketPath(); err == nil {
+				searcher := cfg.App.NewSearcherWithWeights(windowWeights)
+				app.windows = i3ipc.NewProvider(path, searcher, app.apps.WindowApp)
+				app.idx.Register(app.windows)
+			}
 		}
 
-		app.idx = appindex.NewIndex(searcher)
-		app.idx.SortType = desktopentry.EntrySortedAlphabetically
-		app.idx.MaxAge = cfg.App.IndexAge
-		app.idx.Reindex()
+		if emojis := cfg.Providers.Emoji; emojis.Prefix != "" {
+			app.idx.Register(emoji.NewProvider(
+				emojis.Prefix, emojis.Characters, emojis.Annotations, copyToClipboard,
+			))
+		}
+
+		if powerCfg := cfg.Providers.Power; powerCfg.Enabled {
+			app.idx.Register(power.NewProvider(powerCfg.Commands(), powerCfg.Confirm, runCommand))
+		}
+
+		app.idx.Register(websearch.NewProvider(cfg.Providers.Engines(), openURI))
+
+		if cmds := cfg.Providers.Commands; cmds.Prefix != "" {
+			app.idx.Register(newCommandsProvider(cmds))
+		}
 
 		// app.pbc = pixbufcache.NewCache(app.cfg.App.IconSize)
-	} else {
+	} else if !app.apps.Watching() {
 		// Asynchronously refresh the cache. This will be pretty much instant.
 		if !app.reindexing {
 			app.reindexing = true
 
 			go func() {
-				app.idx.Reindex()
+				app.apps.Reindex()
 				glib.IdleAdd(func() { app.reindexing = false })
 			}()
 		}
+	} else {
+		// The index is up to date, but launches change the frecency order.
+		app.apps.Resort()
+	}
+
+	// List the windows once per opening instead of on every search.
+	if app.windows != nil {
+		app.windows.Refresh()
 	}
 
 	// See if we already have a window. Reuse that if possible.
@@ -101,6 +243,8 @@ func activate(gapp *gtk.Application) {
 type window struct {
 	*gtk.ApplicationWindow
 	entry *gtk.Entry
+	// refresh updates the shown entries from the index.
+	refresh func()
 }
 
 func openWindow() *window {
@@ -135,112 +279,121 @@ func openWindow() *window {
 
 	w.Show()
 
-	grid := gtk.NewFlowBox()
-	grid.SetActivateOnSingleClick(true)
-	grid.SetVAlign(gtk.AlignStart)
-	grid.SetHAlign(gtk.AlignCenter)
-	grid.SetHomogeneous(true)
-	grid.SetMinChildrenPerLine(app.cfg.App.Grid.MinChildrenPerLine)
-	grid.SetMaxChildrenPerLine(app.cfg.App.Grid.MaxChildrenPerLine)
-	grid.SetSelectionMode(gtk.SelectionSingle)
-	grid.Show()
-	addCSSClass(grid, "app-grid")
-
-	entries := app.idx.AllEntries()
-
-	grid.Connect("child-activated", func(child *gtk.FlowBoxChild) {
-		desktopentry.Exec(entries[child.Index()])
+	stack := gtk.NewStack()
+
+	// confirm is created once the search entry exists, since cancelling
+	// returns to it.
+	var confirm *confirmPage
+
+	activate := func(item appindex.Item) {
+		if item.Confirm != "" {
+			stack.SetVisibleChild(confirm)
+			confirm.Ask(item)
+			return
+		}
+
+		item.Activate()
 		shutWindow()
-	})
+	}
 
+	mainView := newResultView(activate)
 	noResults := noResultsPage()
 
-	stack := gtk.NewStack()
-	stack.AddNamed(grid, "main")
+	// The favorites are shown above the main view while the query is empty.
+	favorites := newFavoritesRow(activate)
+
+	mainPage := gtk.NewBox(gtk.OrientationVertical, 0)
+	mainPage.Add(favorites)
+	mainPage.Add(mainView)
+	mainPage.Show()
+
+	stack.AddNamed(mainPage, "main")
 	stack.AddNamed(noResults, "no-results")
 	stack.SetTransitionDuration(100)
 	stack.SetTransitionType(gtk.StackTransitionTypeCrossfade)
 	stack.Show()
 
-	update := func() {
-		// Remove all children.
-		for _, widget := range grid.Children() {
-			gtk.BaseWidget(widget).Destroy()
-		}
-
-		if len(entries) == 0 {
-			stack.SetVisibleChild(noResults)
-			return
-		}
+	// Glyphs such as emoji are always shown in a grid.
+	glyphView := mainView
+	if _, ok := mainView.(*gridView); !ok {
+		glyphView = newGridView(activate)
+		stack.AddNamed(glyphView, "glyphs")
+	}
 
-		stack.SetVisibleChild(grid)
+	// view is the shown view.
+	view := mainView
 
-		iconSize := int(app.cfg.App.StockIconSize())
+	update := func(items []appindex.Item, empty bool) {
+		if empty {
+			favorites.SetItems(app.apps.Items(app.favorites.IDs()))
+		} else {
+			favorites.SetItems(nil)
+		}
 
-		for i, entry := range entries {
-			var icon *gtk.Image
-			if gicon := entry.Icon(); gicon != nil {
-				icon = gtk.NewImageFromGIcon(gicon, iconSize)
-			} else {
-				icon = gtk.NewImageFromIconName("image-missing", iconSize)
-			}
-			name := entry.DisplayName()
-
-			label := gtk.NewLabel(name)
-			label.SetTooltipText(name)
-			label.SetYAlign(1)
-			singlelineLabel(label)
-
-			overlay := gtk.NewOverlay()
-			overlay.Add(icon)
-			overlay.AddOverlay(label)
-
-			evbox := gtk.NewEventBox()
-			addCSSClass(evbox, "grid-item")
-			evbox.AddEvents(int(gdk.EnterNotifyMask | gdk.LeaveNotifyMask))
-			evbox.Connect("enter-notify-event", func() {
-				multilineLabel(label)
-				addCSSClass(evbox, "hover")
-			})
-			evbox.Connect("leave-notify-event", func() {
-				singlelineLabel(label)
-				removeCSSClass(evbox, "hover")
-			})
-			evbox.Add(overlay)
+		if favorites.Visible() {
+			addCSSClass(mainPage, "has-favorites")
+		} else {
+			removeCSSClass(mainPage, "has-favorites")
+		}
 
-			child := gtk.NewFlowBoxChild()
-			child.Add(evbox)
+		if allGlyphs(items) {
+			view = glyphView
+		} else {
+			view = mainView
+		}
 
-			if i == 0 {
-				grid.SelectChild(child)
-			}
+		view.SetItems(items)
 
-			grid.Add(child)
+		if len(items) == 0 && !favorites.Visible() {
+			stack.SetVisibleChild(noResults)
+			return
 		}
 
-		grid.ShowAll()
+		if view == mainView {
+			stack.SetVisibleChild(mainPage)
+		} else {
+			stack.SetVisibleChild(view)
+		}
 	}
 
-	update()
-
 	scroll := gtk.NewScrolledWindow(nil, nil)
 	scroll.Add(stack)
 	scroll.Show()
 
 	buffer := gtk.NewEntryBuffer("", -1)
+	entry := gtk.NewEntryWithBuffer(buffer)
+
+	// categories is nil if the category bar is disabled.
+	var categories *categoryBar
 
 	updateBuffer := func() {
-		if text := buffer.Text(); text != "" {
-			entries = app.idx.Search(text)
-		} else {
-			entries = app.idx.AllEntries()
+		var category string
+		if categories != nil {
+			category = categories.Category()
+		}
+
+		switch text := buffer.Text(); {
+		case strings.TrimSpace(text) == "":
+			update(appindex.FilterCategory(app.idx.List(), category), category == "")
+		case app.idx.Prefixed(text):
+			// Modes such as commands have nothing to do with categories.
+			update(app.idx.Search(text), false)
+		default:
+			update(appindex.FilterCategory(app.idx.Search(text), category), false)
 		}
-		update()
 	}
 	buffer.Connect("deleted-text", updateBuffer)
 	buffer.Connect("inserted-text", updateBuffer)
 
-	entry := gtk.NewEntryWithBuffer(buffer)
+	if app.cfg.App.Categories {
+		categories = newCategoryBar(func() {
+			updateBuffer()
+			entry.GrabFocus()
+		})
+	}
+
+	updateBuffer()
+
 	entry.SetHAlign(gtk.AlignCenter)
 	entry.SetVAlign(gtk.AlignCenter)
 	entry.SetVExpand(true)
@@ -249,11 +402,138 @@ func openWindow() *window {
 
 	entry.Connect("activate", func() {
 		// On Enter, activate entry if any.
-		if selected := grid.SelectedChildren(); len(selected) > 0 {
-			selected[0].Activate()
+		view.ActivateSelected()
+	})
+
+	entryBox := gtk.NewBox(gtk.OrientationVertical, 0)
+	entryBox.SetVAlign(gtk.AlignStart)
+	entryBox.SetHExpand(true)
+	entryBox.Add(entry)
+	addCSSClass(entryBox, "search-entry-box")
+
+	content := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	if categories != nil {
+		content.Add(categories)
+	}
+	content.PackStart(scroll, true, true, 0)
+
+	// moveSelection moves the selection of the shown view and scrolls to it. It
+	// returns false if the view cannot move in the given step.
+	moveSelection := func(step selectionStep, count int) bool {
+		if !view.MoveSelection(step, count) {
+			return false
+		}
+
+		scrollToSelected(scroll, stack, view, entryBox.AllocatedHeight())
+		return true
+	}
+
+	// The entry keeps the focus, so the keys that move the selection are
+	// handled here while typing goes on.
+	entry.Connect("key-press-event", func(event *gdk.Event) bool {
+		keyEvent := event.AsKey()
+
+		ctrl := keyEvent.State()&gdk.ControlMask != 0
+		// Shift selects text with the keys that move the cursor.
+		shift := keyEvent.State()&gdk.ShiftMask != 0
+
+		switch keyEvent.Keyval() {
+		case gdk.KEY_Menu:
+			// Override the entry's own context menu.
+			view.PopupSelectedActions()
+			return true
+		case gdk.KEY_Up:
+			moveSelection(stepLine, -1)
+			return true
+		case gdk.KEY_Down:
+			moveSelection(stepLine, 1)
+			return true
+		case gdk.KEY_Left:
+			return !shift && moveSelection(stepColumn, -1)
+		case gdk.KEY_Right:
+			return !shift && moveSelection(stepColumn, 1)
+		case gdk.KEY_Home:
+			return !shift && moveSelection(stepEnd, -1)
+		case gdk.KEY_End:
+			return !shift && moveSelection(stepEnd, 1)
+		case gdk.KEY_Tab:
+			// Complete the selected item, such as the name of an executable
+			// in command mode.
+			if item, ok := view.SelectedItem(); ok && item.Completion != "" {
+				entry.SetText(item.Completion)
+				entry.SetPosition(-1)
+				return true
+			}
+
+			// Don't let Tab move the focus away from the entry.
+			moveSelection(stepItem, 1)
+			return true
+		case gdk.KEY_ISO_Left_Tab:
+			moveSelection(stepItem, -1)
+			return true
+		case gdk.KEY_n, gdk.KEY_j:
+			if ctrl {
+				moveSelection(stepItem, 1)
+				return true
+			}
+		case gdk.KEY_p, gdk.KEY_k:
+			if ctrl {
+				moveSelection(stepItem, -1)
+				return true
+			}
+		case gdk.KEY_d:
+			if ctrl {
+				if item, ok := view.SelectedItem(); ok {
+					toggleFavorite(item)
+				}
+				return true
+			}
+		case gdk.KEY_Page_Up, gdk.KEY_Page_Down:
+			count := 1
+			if keyEvent.Keyval() == gdk.KEY_Page_Up {
+				count = -1
+			}
+
+			if categories != nil && ctrl {
+				categories.Cycle(count)
+				return true
+			}
+
+			moveSelection(stepPage, count)
+			return true
+		case gdk.KEY_Return, gdk.KEY_KP_Enter:
+			if shift {
+				view.PopupSelectedActions()
+				return true
+			}
+		}
+
+		// Alt+0 to Alt+9 select a category.
+		if categories != nil && keyEvent.State()&gdk.Mod1Mask != 0 {
+			if key := keyEvent.Keyval(); key >= gdk.KEY_0 && key <= gdk.KEY_9 {
+				categories.Select(int(key - gdk.KEY_0))
+				return true
+			}
 		}
+
+		return false
 	})
 
+	// cancelConfirm returns from the confirmation page to the results.
+	cancelConfirm := func() {
+		updateBuffer()
+		entry.GrabFocus()
+	}
+
+	confirm = newConfirmPage(
+		func(item appindex.Item) {
+			item.Activate()
+			shutWindow()
+		},
+		cancelConfirm,
+	)
+	stack.AddNamed(confirm, "confirm")
+
 	// Focus on the input if the window is focused.
 	w.Connect("notify::is-active", func() {
 		if w.IsActive() {
@@ -261,14 +541,8 @@ func openWindow() *window {
 		}
 	})
 
-	entryBox := gtk.NewBox(gtk.OrientationVertical, 0)
-	entryBox.SetVAlign(gtk.AlignStart)
-	entryBox.SetHExpand(true)
-	entryBox.Add(entry)
-	addCSSClass(entryBox, "search-entry-box")
-
 	overlay := gtk.NewOverlay()
-	overlay.Add(scroll)
+	overlay.Add(content)
 	overlay.AddOverlay(entryBox)
 
 	addCSSClass(w, "gappdash-window")
@@ -279,7 +553,11 @@ func openWindow() *window {
 	w.ConnectAfter("key-press-event", func(event *gdk.Event) bool {
 		switch keyEvent := event.AsKey(); keyEvent.Keyval() {
 		case gdk.KEY_Escape:
-			shutWindow()
+			if stack.VisibleChildName() == "confirm" {
+				cancelConfirm()
+			} else {
+				shutWindow()
+			}
 			return true
 		}
 
@@ -289,6 +567,51 @@ func openWindow() *window {
 	return &window{
 		ApplicationWindow: w,
 		entry:             entry,
+		refresh:           updateBuffer,
+	}
+}
+
+// toggleFavorite adds the item to the favorites or removes it from them, then
+// refreshes the window. It does nothing if the item cannot be a favorite.
+func toggleFavorite(item appindex.Item) {
+	if item.FavoriteID == "" {
+		return
+	}
+
+	if _, err := app.favorites.Toggle(item.FavoriteID); err != nil {
+		log.Println("cannot save favorites:", err)
+	}
+
+	if app.window != nil {
+		app.window.refresh()
+	}
+}
+
+// scrollToSelected scrolls the scrolled window so that the selected item of the
+// view is shown below the search entry, which covers the given height at the
+// top. content is the child of the scrolled window.
+func scrollToSelected(scroll *gtk.ScrolledWindow, content gtk.Widgetter, view resultView, top int) {
+	selected := view.SelectedWidget()
+	if selected == nil {
+		return
+	}
+
+	widget := gtk.BaseWidget(selected)
+
+	_, y, ok := widget.TranslateCoordinates(content, 0, 0)
+	if !ok {
+		return
+	}
+
+	adj := scroll.VAdjustment()
+	start := float64(y - top)
+	end := float64(y + widget.AllocatedHeight())
+
+	switch {
+	case start < adj.Value():
+		adj.SetValue(start)
+	case end > adj.Value()+adj.PageSize():
+		adj.SetValue(end - adj.PageSize())
 	}
 }
 
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+)
+
+// defaultItemSize is the size of a grid item in pixels before one is shown to
+// measure, which is the minimum size of .app-grid > flowboxchild.
+const defaultItemSize = 120
+
+// pagedGridView is the resultView for GridMode with paged enabled. The items
+// are split into pages that fill the available space, and only one page is
+// shown at a time.
+type pagedGridView struct {
+	*gtk.EventBox
+	viewport *gtk.ScrolledWindow
+	stack    *gtk.Stack
+	dots     *gtk.Box
+
+	items    []appindex.Item
+	activate func(appindex.Item)
+
+	pages   []*gridView
+	current int
+	columns int
+	rows    int
+
+	// scrollDelta accumulates smooth scrolling until it flips a page.
+	scrollDelta float64
+}
+
+func newPagedGridView(activate func(appindex.Item)) *pagedGridView {
+	stack := gtk.NewStack()
+	stack.SetTransitionType(gtk.StackTransitionTypeSlideLeftRight)
+	stack.SetTransitionDuration(200)
+
+	// The viewport is only there so that the pages never make the view larger
+	// than the space it is given, since the pages are sized from it.
+	viewport := gtk.NewScrolledWindow(nil, nil)
+	viewport.SetPolicy(gtk.PolicyExternal, gtk.PolicyExternal)
+	viewport.SetVExpand(true)
+	viewport.Add(stack)
+
+	dots := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	dots.SetHAlign(gtk.AlignCenter)
+	addCSSClass(dots, "page-dots")
+
+	box := gtk.NewBox(gtk.OrientationVertical, 0)
+	box.Add(viewport)
+	box.Add(dots)
+	addCSSClass(box, "paged-grid")
+
+	evbox := gtk.NewEventBox()
+	evbox.SetVExpand(true)
+	evbox.AddEvents(int(gdk.ScrollMask | gdk.SmoothScrollMask))
+	evbox.Add(box)
+	evbox.ShowAll()
+
+	v := &pagedGridView{
+		EventBox: evbox,
+		viewport: viewport,
+		stack:    stack,
+		dots:     dots,
+		activate: activate,
+		columns:  clampColumns(int(app.cfg.App.Grid.MaxChildrenPerLine)),
+		rows:     1,
+	}
+
+	evbox.Connect("scroll-event", v.scroll)
+
+	viewport.ConnectSizeAllocate(func(*gtk.Allocation) {
+		// Don't change the layout in the middle of allocating it.
+		glib.IdleAdd(v.resize)
+	})
+
+	return v
+}
+
+func (v *pagedGridView) SetItems(items []appindex.Item) {
+	v.items = items
+	v.paginate(0)
+}
+
+func (v *pagedGridView) SelectedItem() (appindex.Item, bool) {
+	if page := v.currentPage(); page != nil {
+		return page.SelectedItem()
+	}
+	return appindex.Item{}, false
+}
+
+func (v *pagedGridView) SelectedWidget() gtk.Widgetter {
+	if page := v.currentPage(); page != nil {
+		return page.SelectedWidget()
+	}
+	return nil
+}
+
+// MoveSelection implements resultView. The selection moves across pages, and
+// stepPage flips the page.
+func (v *pagedGridView) MoveSelection(step selectionStep, count int) bool {
+	page := v.currentPage()
+	if page == nil {
+		return false
+	}
+
+	size := v.columns * v.rows
+
+	index := v.current * size
+	if selected := page.SelectedChildren(); len(selected) > 0 {
+		index += selected[0].Index()
+	}
+
+	index = moveIndex(index, len(v.items), v.columns, v.rows, step, count)
+	if step == stepPage {
+		// Keep the selection on the first item of the page like flipping
+		// does otherwise.
+		index -= index % size
+	}
+
+	v.SetPage(index / size)
+
+	page = v.pages[v.current]
+	page.SelectChild(page.ChildAtIndex(index % size))
+	return true
+}
+
+func (v *pagedGridView) ActivateSelected() {
+	if page := v.currentPage(); page != nil {
+		page.ActivateSelected()
+	}
+}
+
+func (v *pagedGridView) PopupSelectedActions() {
+	if page := v.currentPage(); page != nil {
+		page.PopupSelectedActions()
+	}
+}
+
+// NextPage shows the next page, if any.
+func (v *pagedGridView) NextPage() {
+	v.SetPage(v.current + 1)
+}
+
+// PreviousPage shows the previous page, if any.
+func (v *pagedGridView) PreviousPage() {
+	v.SetPage(v.current - 1)
+}
+
+// SetPage shows the page at the given index and selects its first item. It
+// does nothing if there is no such page.
+func (v *pagedGridView) SetPage(index int) {
+	if index < 0 || index >= len(v.pages) {
+		return
+	}
+
+	v.pages[v.current].UnselectAll()
+	v.current = index
+
+	page := v.pages[index]
+	page.SelectChild(page.ChildAtIndex(0))
+	v.stack.SetVisibleChild(page)
+
+	for i, dot := range v.dots.Children() {
+		if i == index {
+			addCSSClass(dot, "current")
+		} else {
+			removeCSSClass(dot, "current")
+		}
+	}
+}
+
+func (v *pagedGridView) currentPage() *gridView {
+	if v.current < len(v.pages) {
+		return v.pages[v.current]
+	}
+	return nil
+}
+
+// paginate splits the items into pages and shows the page with the item at the
+// given index.
+func (v *pagedGridView) paginate(item int) {
+	for _, page := range v.pages {
+		page.Destroy()
+	}
+	removeChildren(&v.dots.Container)
+
+	v.pages = v.pages[:0]
+	v.current = 0
+
+	size := v.columns * v.rows
+
+	for start := 0; start < len(v.items); start += size {
+		end := start + size
+		if end > len(v.items) {
+			end = len(v.items)
+		}
+
+		page := newGridView(v.activate)
+		page.SetMinChildrenPerLine(uint(v.columns))
+		page.SetMaxChildrenPerLine(uint(v.columns))
+		page.SetItems(v.items[start:end])
+		page.UnselectAll()
+
+		index := len(v.pages)
+		v.pages = append(v.pages, page)
+		v.stack.AddNamed(page, strconv.Itoa(index))
+
+		dot := gtk.NewButton()
+		dot.SetCanFocus(false)
+		dot.SetTooltipText("Page " + strconv.Itoa(index+1))
+		dot.ConnectClicked(func() { v.SetPage(index) })
+		addCSSClass(dot, "page-dot")
+		v.dots.Add(dot)
+	}
+
+	// There is nothing to flip through with a single page.
+	v.dots.ShowAll()
+	v.dots.SetVisible(len(v.pages) > 1)
+
+	if len(v.pages) > 0 {
+		v.SetPage(item / size)
+	}
+}
+
+// resize updates the number of columns and rows to fit the viewport, keeping
+// the first item of the shown page shown.
+func (v *pagedGridView) resize() {
+	width, height := defaultItemSize, defaultItemSize
+
+	if page := v.currentPage(); page != nil {
+		if child := page.ChildAtIndex(0); child != nil && child.AllocatedHeight() > 1 {
+			width = child.AllocatedWidth()
+			height = child.AllocatedHeight()
+		}
+	}
+
+	columns := clampColumns(v.viewport.AllocatedWidth() / width)
+
+	rows := v.viewport.AllocatedHeight() / height
+	if rows < 1 {
+		rows = 1
+	}
+
+	if columns == v.columns && rows == v.rows {
+		return
+	}
+
+	first := v.current * v.columns * v.rows
+	v.columns = columns
+	v.rows = rows
+	v.paginate(first)
+}
+
+// clampColumns clamps the number of columns to the configured children per
+// line. There is always at least one column.
+func clampColumns(columns int) int {
+	grid := app.cfg.App.Grid
+
+	if max := int(grid.MaxChildrenPerLine); max > 0 && columns > max {
+		columns = max
+	}
+	if columns < int(grid.MinChildrenPerLine) {
+		columns = int(grid.MinChildrenPerLine)
+	}
+	if columns < 1 {
+		columns = 1
+	}
+
+	return columns
+}
+
+// scroll flips the pages with the scroll wheel.
+func (v *pagedGridView) scroll(event *gdk.Event) bool {
+	scrollEvent := event.AsScroll()
+
+	switch scrollEvent.Direction() {
+	case gdk.ScrollUp, gdk.ScrollLeft:
+		v.PreviousPage()
+	case gdk.ScrollDown, gdk.ScrollRight:
+		v.NextPage()
+	case gdk.ScrollSmooth:
+		v.scrollDelta += scrollEvent.DeltaY() + scrollEvent.DeltaX()
+
+		switch {
+		case v.scrollDelta <= -1:
+			v.scrollDelta = 0
+			v.PreviousPage()
+		case v.scrollDelta >= 1:
+			v.scrollDelta = 0
+			v.NextPage()
+		}
+	}
+
+	return true
+}
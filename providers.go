@@ -0,0 +1,86 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+	"github.com/diamondburned/gappdash/internal/commands"
+	"github.com/diamondburned/gappdash/internal/desktopentry"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/gio/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+)
+
+// copyToClipboard copies the given text to the clipboard. The text is stored
+// so that it stays around after the window is closed.
+func copyToClipboard(text string) {
+	clipboard := gtk.ClipboardGetDefault(gdk.DisplayGetDefault())
+	clipboard.SetText(text, -1)
+	clipboard.Store()
+}
+
+// runCommand runs the given command through the shell in the background.
+func runCommand(command string) {
+	if err := app.cfg.Providers.Commands.Runner().Run(command, false); err != nil {
+		log.Println("cannot run command:", err)
+	}
+}
+
+// newCommandsProvider creates the provider of the command mode. The history
+// is kept in memory only if it cannot be loaded.
+func newCommandsProvider(cfg CommandsConfig) *commands.Provider {
+	var path string
+	if p, err := desktopentry.StateFile("commands"); err == nil {
+		path = p
+	} else {
+		log.Println("cannot save command history:", err)
+	}
+
+	history, err := commands.OpenHistory(path, cfg.HistorySize)
+	if err != nil {
+		log.Println("cannot load command history:", err)
+	}
+
+	return commands.NewProvider(cfg.Prefix, cfg.Runner(), history, app.executables.Executables)
+}
+
+// windowWeights are the weights of the records of windows. See
+// i3ipc.NewProvider.
+var windowWeights = appindex.Weights{
+	appindex.FieldName:        1,
+	appindex.FieldDescription: 0.6,
+	appindex.FieldID:          0.4,
+}
+
+// recentWeights are the weights of the records of recent files. Only the name
+// and the directory of a file are searched.
+var recentWeights = appindex.Weights{
+	appindex.FieldName:        1,
+	appindex.FieldDescription: 0.2,
+}
+
+// mimeIcon returns the icon of the given MIME type, falling back to its
+// generic icon if the icon theme does not have a specific one.
+func mimeIcon(mimeType string) string {
+	if mimeType != "" {
+		icon := strings.ReplaceAll(mimeType, "/", "-")
+		if gtk.IconThemeGetDefault().HasIcon(icon) {
+			return icon
+		}
+
+		contentType := gio.ContentTypeFromMIMEType(mimeType)
+		if generic := gio.ContentTypeGetGenericIconName(contentType); generic != "" {
+			return generic
+		}
+	}
+
+	return "text-x-generic"
+}
+
+// openURI opens the given URI with its default application.
+func openURI(uri string) {
+	if err := gio.AppInfoLaunchDefaultForURI(uri, nil); err != nil {
+		log.Println("cannot open", uri+":", err)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/diamondburned/gappdash/internal/appindex"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/gio/v2"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gotk4/pkg/pango"
+)
+
+// resultView describes a widget that displays a list of items. Each AppMode
+// has its own resultView.
+type resultView interface {
+	gtk.Widgetter
+	// SetItems replaces the displayed items with the given ones. The first
+	// item is selected.
+	SetItems(items []appindex.Item)
+	// SelectedItem returns the selected item, if any.
+	SelectedItem() (appindex.Item, bool)
+	// SelectedWidget returns the widget of the selected item, or nil if
+	// nothing is selected.
+	SelectedWidget() gtk.Widgetter
+	// MoveSelection moves the selection by count steps, backwards if count is
+	// negative. It returns false if the view cannot move in the given step,
+	// such as columns in a list.
+	MoveSelection(step selectionStep, count int) bool
+	// ActivateSelected activates the selected item, if any.
+	ActivateSelected()
+	// PopupSelectedActions shows a menu of the actions of the selected item,
+	// if it has any.
+	PopupSelectedActions()
+}
+
+// selectionStep is the unit that resultView.MoveSelection moves by.
+type selectionStep uint8
+
+const (
+	// stepItem moves to the next or the previous item.
+	stepItem selectionStep = iota
+	// stepColumn moves to the item on the left or on the right. Only grids
+	// have columns.
+	stepColumn
+	// stepLine moves to the item above or below.
+	stepLine
+	// stepPage moves by the lines that fit the window.
+	stepPage
+	// stepEnd moves to the first or the last item.
+	stepEnd
+)
+
+// moveIndex returns the index of the item that the selection moves to from
+// the item at index, given n items laid out in the given number of columns
+// with the given number of lines per page. The index stays within the items.
+func moveIndex(index, n, columns, lines int, step selectionStep, count int) int {
+	switch step {
+	case stepItem, stepColumn:
+		index += count
+	case stepLine:
+		index += count * columns
+	case stepPage:
+		index += count * columns * lines
+	case stepEnd:
+		if count < 0 {
+			index = 0
+		} else {
+			index = n - 1
+		}
+	}
+
+	if index >= n {
+		index = n - 1
+	}
+	if index < 0 {
+		index = 0
+	}
+
+	return index
+}
+
+// pageLines returns the number of lines of items as tall as the given item that
+// roughly fit the window below the search entry.
+func pageLines(item gtk.Widgetter) int {
+	widget := gtk.BaseWidget(item)
+
+	height := widget.AllocatedHeight()
+	if height < 1 {
+		return 1
+	}
+
+	// Leave out the line that is hidden under the search entry.
+	lines := gtk.BaseWidget(widget.Toplevel()).AllocatedHeight()/height - 1
+	if lines < 1 {
+		return 1
+	}
+
+	return lines
+}
+
+// newResultView creates a new resultView for the configured mode. activate is
+// called when an item is activated.
+func newResultView(activate func(appindex.Item)) resultView {
+	if app.cfg.App.Mode == ListMode {
+		return newListView(activate)
+	}
+
+	// Any other mode is GridMode.
+	if app.cfg.App.Grid.Paged {
+		return newPagedGridView(activate)
+	}
+	return newGridView(activate)
+}
+
+// newItemIcon creates the icon of the item, which is a label if the item has a
+// glyph.
+func newItemIcon(item appindex.Item) gtk.Widgetter {
+	if glyph := item.Glyph; glyph != "" {
+		// Scale the glyph to the icon size, which is in pixels, assuming 96
+		// DPI.
+		size := app.cfg.App.IconSize * pango.SCALE * 3 / 4
+
+		label := gtk.NewLabel("")
+		label.SetMarkup(fmt.Sprintf(`<span size="%d">%s</span>`, size, glib.MarkupEscapeText(glyph, -1)))
+		addCSSClass(label, "item-glyph")
+		return label
+	}
+
+	iconSize := int(app.cfg.App.StockIconSize())
+
+	if icon := item.Icon; icon != "" {
+		if gicon, err := gio.NewIconForString(icon); err == nil {
+			return gtk.NewImageFromGIcon(gicon, iconSize)
+		}
+	}
+	return gtk.NewImageFromIconName("image-missing", iconSize)
+}
+
+// newActionsMenu creates a menu that lists the actions of the item, followed by
+// an entry to add it to or remove it from the favorites if it can be one. Nil
+// is returned if the menu would be empty.
+func newActionsMenu(item appindex.Item, activate func(appindex.Item)) *gtk.Menu {
+	var actions []appindex.Item
+	if item.Actions != nil {
+		actions = item.Actions()
+	}
+
+	if len(actions) == 0 && item.FavoriteID == "" {
+		return nil
+	}
+
+	menu := gtk.NewMenu()
+	addCSSClass(menu, "actions-menu")
+
+	for _, action := range actions {
+		action := action
+
+		menuItem := gtk.NewMenuItemWithLabel(action.Title)
+		menuItem.ConnectActivate(func() { activate(action) })
+		menu.Append(menuItem)
+	}
+
+	if item.FavoriteID != "" {
+		if len(actions) > 0 {
+			menu.Append(&gtk.NewSeparatorMenuItem().MenuItem)
+		}
+
+		label := "Add to Favorites"
+		if app.favorites.Has(item.FavoriteID) {
+			label = "Remove from Favorites"
+		}
+
+		menuItem := gtk.NewMenuItemWithLabel(label)
+		menuItem.ConnectActivate(func() { toggleFavorite(item) })
+		menu.Append(menuItem)
+	}
+
+	menu.ShowAll()
+	return menu
+}
+
+// newHighlightedLabel creates a label with the given text. The given ranges
+// of the text are highlighted using the color of the search-highlight CSS
+// class, since Pango markup cannot be styled with CSS directly.
+func newHighlightedLabel(text string, ranges []appindex.Range) *gtk.Label {
+	label := gtk.NewLabel(text)
+	if len(ranges) == 0 {
+		return label
+	}
+
+	update := func() {
+		ctx := label.StyleContext()
+		ctx.Save()
+		ctx.AddClass("search-highlight")
+		color := ctx.Color(ctx.State())
+		ctx.Restore()
+
+		// The style is updated when the label is added to the window and when
+		// its state changes, so avoid doing unnecessary work.
+		if markup := highlightMarkup(text, ranges, color); markup != label.Label() {
+			label.SetMarkup(markup)
+		}
+	}
+
+	update()
+	label.ConnectStyleUpdated(update)
+
+	return label
+}
+
+// highlightMarkup returns the Pango markup of text with the given ranges in
+// bold and in the given color.
+func highlightMarkup(text string, ranges []appindex.Range, color *gdk.RGBA) string {
+	open := fmt.Sprintf(
+		`<span weight="bold" foreground="#%02X%02X%02X">`,
+		int(color.Red()*255), int(color.Green()*255), int(color.Blue()*255),
+	)
+
+	var b strings.Builder
+	var last int
+
+	for _, r := range ranges {
+		if r.Start < last || r.End > len(text) {
+			// The ranges are for another string.
+			return glib.MarkupEscapeText(text, -1)
+		}
+
+		b.WriteString(glib.MarkupEscapeText(text[last:r.Start], -1))
+		b.WriteString(open)
+		b.WriteString(glib.MarkupEscapeText(text[r.Start:r.End], -1))
+		b.WriteString("</span>")
+		last = r.End
+	}
+
+	b.WriteString(glib.MarkupEscapeText(text[last:], -1))
+	return b.String()
+}
+
+// allGlyphs returns true if there are items and all of them have glyphs.
+func allGlyphs(items []appindex.Item) bool {
+	for _, item := range items {
+		if item.Glyph == "" {
+			return false
+		}
+	}
+	return len(items) > 0
+}
+
+func removeChildren(container *gtk.Container) {
+	for _, widget := range container.Children() {
+		gtk.BaseWidget(widget).Destroy()
+	}
+}